@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	"github.com/leveleven/smtool/internal/postformat"
+	"github.com/spf13/cobra"
+)
+
+var (
+	analyzePath   string
+	analyzeSample float64
+)
+
+var analyzeLabelsCmd = &cobra.Command{
+	Use:   "analyze-labels",
+	Short: "statistically test sampled labels for non-randomness",
+	Long: `Sample labels from a plot and compute entropy, byte-frequency and
+chi-square statistics. Healthy label data is indistinguishable from
+random bytes; a skewed distribution (especially runs of zero labels)
+is the signature of the zero-filled GPU bug and is detectable without
+knowing the commitment.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := resolvePath(analyzePath)
+		if err != nil {
+			fail(err)
+			return
+		}
+		if err := analyzeLabels(dir, uint64(analyzeSample)); err != nil {
+			fail(err)
+		}
+	},
+}
+
+func init() {
+	analyzeLabelsCmd.Flags().StringVar(&analyzePath, "path", "",
+		"plot directory (default: discovered data directory)")
+	analyzeLabelsCmd.Flags().Float64Var(&analyzeSample, "sample", 1e6,
+		"number of labels to sample (scientific notation accepted)")
+	rootCmd.AddCommand(analyzeLabelsCmd)
+}
+
+func analyzeLabels(dir string, samples uint64) error {
+	metadata, err := loadMetadata(dir)
+	if err != nil {
+		return err
+	}
+	format := postformat.ForMetadata(metadata)
+	numLabels := uint64(metadata.NumUnits) * metadata.LabelsPerUnit
+	if numLabels == 0 {
+		return fmt.Errorf("metadata reports zero labels")
+	}
+	if samples > numLabels {
+		samples = numLabels
+	}
+	labelsPerFile := metadata.MaxFileSize / format.LabelSize
+	if labelsPerFile == 0 {
+		return fmt.Errorf("metadata reports MaxFileSize %d, smaller than one label", metadata.MaxFileSize)
+	}
+
+	var histogram [256]uint64
+	var zeroLabels, read uint64
+	label := make([]byte, format.LabelSize)
+	for i := uint64(0); i < samples; i++ {
+		position := rand.Uint64() % numLabels
+		file, err := os.Open(filepath.Join(dir, fmt.Sprintf("postdata_%d.bin", position/labelsPerFile)))
+		if err != nil {
+			continue
+		}
+		_, err = file.ReadAt(label, int64((position%labelsPerFile)*format.LabelSize))
+		file.Close()
+		if err != nil {
+			continue
+		}
+		read++
+		zero := true
+		for _, b := range label {
+			histogram[b]++
+			if b != 0 {
+				zero = false
+			}
+		}
+		if zero {
+			zeroLabels++
+		}
+	}
+	if read == 0 {
+		return fmt.Errorf("no labels could be read; are the postdata_*.bin files present?")
+	}
+
+	totalBytes := float64(read) * float64(format.LabelSize)
+	expected := totalBytes / 256
+
+	entropy := 0.0
+	chiSquare := 0.0
+	var minByte, maxByte uint64 = math.MaxUint64, 0
+	for _, count := range histogram {
+		if count > 0 {
+			p := float64(count) / totalBytes
+			entropy -= p * math.Log2(p)
+		}
+		deviation := float64(count) - expected
+		chiSquare += deviation * deviation / expected
+		if count < minByte {
+			minByte = count
+		}
+		if count > maxByte {
+			maxByte = count
+		}
+	}
+
+	fmt.Printf("sampled %d labels (%s)\n", read, formatBytes(uint64(totalBytes)))
+	fmt.Printf("entropy:     %.4f bits/byte (random data: ~8.0000)\n", entropy)
+	fmt.Printf("chi-square:  %.1f over 255 degrees of freedom (random data: ~255)\n", chiSquare)
+	fmt.Printf("byte counts: min %d, max %d, expected %.0f\n", minByte, maxByte, expected)
+	fmt.Printf("zero labels: %d\n", zeroLabels)
+
+	// 3*sqrt(2*255) above the mean is far outside what random data
+	// produces at these sample sizes.
+	suspicious := chiSquare > 255+3*math.Sqrt(2*255)
+	if zeroLabels > read/1000 {
+		fmt.Printf("SUSPICIOUS: %.2f%% of sampled labels are all-zero — this matches the zero-filled GPU bug\n",
+			float64(zeroLabels)/float64(read)*100)
+		suspicious = true
+	}
+	if entropy < 7.99 {
+		fmt.Println("SUSPICIOUS: entropy is measurably below random; the label data is structured")
+		suspicious = true
+	}
+	if suspicious {
+		fmt.Println("verdict: label data does NOT look random; verify this plot against its commitment")
+	} else {
+		fmt.Println("verdict: label data is statistically consistent with healthy plot output")
+	}
+	return nil
+}
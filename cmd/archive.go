@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/leveleven/smtool/internal/archive"
+	"github.com/leveleven/smtool/internal/audit"
+	"github.com/spf13/cobra"
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "pack plots into a verifiable cold-storage container",
+}
+
+var archiveCreateCmd = &cobra.Command{
+	Use:   "create <plot dir> <out.smarch>",
+	Short: "archive a plot directory (keys excluded)",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := archive.Create(args[0], args[1]); err != nil {
+			fail(err)
+			return
+		}
+		fmt.Printf("archive written to %s\n", args[1])
+	},
+}
+
+var archiveExtractCmd = &cobra.Command{
+	Use:   "extract <archive.smarch> <dest dir>",
+	Short: "restore an archive, verifying every chunk",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := archive.Extract(args[0], args[1])
+		audit.Record("archive extract", args[1], map[string]string{"archive": args[0]}, err)
+		if err != nil {
+			fail(err)
+			return
+		}
+		fmt.Println("archive extracted")
+	},
+}
+
+var archiveVerifyCmd = &cobra.Command{
+	Use:   "verify <archive.smarch>",
+	Short: "check all chunk digests without extracting",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := archive.Verify(args[0]); err != nil {
+			fail(err)
+			return
+		}
+		fmt.Println("archive is intact")
+	},
+}
+
+func init() {
+	archiveCmd.AddCommand(archiveCreateCmd, archiveExtractCmd, archiveVerifyCmd)
+	rootCmd.AddCommand(archiveCmd)
+}
@@ -0,0 +1,338 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/leveleven/smtool/internal/audit"
+	"github.com/leveleven/smtool/internal/plots"
+	"github.com/spf13/cobra"
+)
+
+var assertPath string
+
+var assertCmd = &cobra.Command{
+	Use:   "assert <expression>",
+	Short: "evaluate a health assertion against a plot, exiting non-zero on failure",
+	Long: `Evaluate a small boolean expression over a plot's state, for cron and
+CI health checks. Identifiers: nonce, numUnits, labelsPerUnit,
+maxFileSize, lastPosition, labelFiles, sizeOnDisk. Functions:
+verified_within_days(n) checks the audit log for a clean verification.
+Operators: == != < <= > >= && || ! and parentheses; null, true and
+false are literals.
+
+Example:
+  smtool assert --path /plot 'nonce != null && numUnits >= 4 && verified_within_days(30)'`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := resolvePath(assertPath)
+		if err != nil {
+			fail(err)
+			os.Exit(2)
+		}
+		ok, err := evalAssertion(dir, args[0])
+		if err != nil {
+			fail(err)
+			os.Exit(2)
+		}
+		if !ok {
+			fmt.Printf("assertion failed: %s\n", args[0])
+			os.Exit(1)
+		}
+		fmt.Println("assertion holds")
+	},
+}
+
+func init() {
+	assertCmd.Flags().StringVar(&assertPath, "path", "",
+		"plot directory (default: discovered data directory)")
+	rootCmd.AddCommand(assertCmd)
+}
+
+// assertValue is the expression language's value domain: numbers,
+// booleans and null.
+type assertValue struct {
+	number *float64
+	isBool bool
+	value  bool
+	null   bool
+}
+
+func numberValue(f float64) assertValue { return assertValue{number: &f} }
+func boolValue(b bool) assertValue      { return assertValue{isBool: true, value: b} }
+func nullValue() assertValue            { return assertValue{null: true} }
+
+func (v assertValue) truthy() (bool, error) {
+	if v.isBool {
+		return v.value, nil
+	}
+	return false, fmt.Errorf("expected a boolean, got %s", v)
+}
+
+func (v assertValue) String() string {
+	switch {
+	case v.number != nil:
+		return strconv.FormatFloat(*v.number, 'f', -1, 64)
+	case v.isBool:
+		return strconv.FormatBool(v.value)
+	default:
+		return "null"
+	}
+}
+
+// evalAssertion gathers the plot's facts and evaluates the expression.
+func evalAssertion(dir, expression string) (bool, error) {
+	metadata, err := loadMetadata(dir)
+	if err != nil {
+		return false, err
+	}
+	facts := map[string]assertValue{
+		"nonce":         nullValue(),
+		"lastPosition":  nullValue(),
+		"numUnits":      numberValue(float64(metadata.NumUnits)),
+		"labelsPerUnit": numberValue(float64(metadata.LabelsPerUnit)),
+		"maxFileSize":   numberValue(float64(metadata.MaxFileSize)),
+		"labelFiles":    numberValue(0),
+		"sizeOnDisk":    numberValue(0),
+	}
+	if metadata.Nonce != nil {
+		facts["nonce"] = numberValue(float64(*metadata.Nonce))
+	}
+	if metadata.LastPosition != nil {
+		facts["lastPosition"] = numberValue(float64(*metadata.LastPosition))
+	}
+	if found, err := plots.Scan(dir); err == nil && len(found) > 0 {
+		facts["labelFiles"] = numberValue(float64(found[0].LabelFiles))
+		facts["sizeOnDisk"] = numberValue(float64(found[0].SizeOnDisk))
+	}
+
+	parser := &assertParser{input: expression, dir: dir, facts: facts}
+	value, err := parser.parseOr()
+	if err != nil {
+		return false, err
+	}
+	parser.skipSpace()
+	if parser.pos < len(parser.input) {
+		return false, fmt.Errorf("unexpected input at %q", parser.input[parser.pos:])
+	}
+	return value.truthy()
+}
+
+// assertParser is a recursive-descent evaluator; the language is small
+// enough that parsing and evaluation happen in one pass.
+type assertParser struct {
+	input string
+	pos   int
+	dir   string
+	facts map[string]assertValue
+}
+
+func (p *assertParser) skipSpace() {
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *assertParser) accept(token string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.input[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+	return false
+}
+
+func (p *assertParser) parseOr() (assertValue, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return assertValue{}, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return assertValue{}, err
+		}
+		l, err := left.truthy()
+		if err != nil {
+			return assertValue{}, err
+		}
+		r, err := right.truthy()
+		if err != nil {
+			return assertValue{}, err
+		}
+		left = boolValue(l || r)
+	}
+	return left, nil
+}
+
+func (p *assertParser) parseAnd() (assertValue, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return assertValue{}, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseComparison()
+		if err != nil {
+			return assertValue{}, err
+		}
+		l, err := left.truthy()
+		if err != nil {
+			return assertValue{}, err
+		}
+		r, err := right.truthy()
+		if err != nil {
+			return assertValue{}, err
+		}
+		left = boolValue(l && r)
+	}
+	return left, nil
+}
+
+func (p *assertParser) parseComparison() (assertValue, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return assertValue{}, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if !p.accept(op) {
+			continue
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return assertValue{}, err
+		}
+		return compare(op, left, right)
+	}
+	return left, nil
+}
+
+func compare(op string, left, right assertValue) (assertValue, error) {
+	switch op {
+	case "==":
+		return boolValue(left.String() == right.String()), nil
+	case "!=":
+		return boolValue(left.String() != right.String()), nil
+	}
+	if left.number == nil || right.number == nil {
+		return assertValue{}, fmt.Errorf("%s needs numbers, got %s and %s", op, left, right)
+	}
+	l, r := *left.number, *right.number
+	switch op {
+	case "<":
+		return boolValue(l < r), nil
+	case "<=":
+		return boolValue(l <= r), nil
+	case ">":
+		return boolValue(l > r), nil
+	default:
+		return boolValue(l >= r), nil
+	}
+}
+
+func (p *assertParser) parseUnary() (assertValue, error) {
+	if p.accept("!") {
+		value, err := p.parseUnary()
+		if err != nil {
+			return assertValue{}, err
+		}
+		b, err := value.truthy()
+		if err != nil {
+			return assertValue{}, err
+		}
+		return boolValue(!b), nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *assertParser) parsePrimary() (assertValue, error) {
+	p.skipSpace()
+	if p.accept("(") {
+		value, err := p.parseOr()
+		if err != nil {
+			return assertValue{}, err
+		}
+		if !p.accept(")") {
+			return assertValue{}, fmt.Errorf("missing )")
+		}
+		return value, nil
+	}
+	if p.pos >= len(p.input) {
+		return assertValue{}, fmt.Errorf("unexpected end of expression")
+	}
+
+	start := p.pos
+	if c := p.input[p.pos]; unicode.IsDigit(rune(c)) {
+		for p.pos < len(p.input) && (unicode.IsDigit(rune(p.input[p.pos])) || p.input[p.pos] == '.') {
+			p.pos++
+		}
+		number, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+		if err != nil {
+			return assertValue{}, err
+		}
+		return numberValue(number), nil
+	}
+
+	for p.pos < len(p.input) && (unicode.IsLetter(rune(p.input[p.pos])) || p.input[p.pos] == '_') {
+		p.pos++
+	}
+	word := p.input[start:p.pos]
+	switch word {
+	case "":
+		return assertValue{}, fmt.Errorf("unexpected character %q", p.input[p.pos])
+	case "null":
+		return nullValue(), nil
+	case "true":
+		return boolValue(true), nil
+	case "false":
+		return boolValue(false), nil
+	}
+	if p.accept("(") {
+		return p.parseCall(word)
+	}
+	if value, ok := p.facts[word]; ok {
+		return value, nil
+	}
+	return assertValue{}, fmt.Errorf("unknown identifier %q", word)
+}
+
+func (p *assertParser) parseCall(name string) (assertValue, error) {
+	argument, err := p.parseOr()
+	if err != nil {
+		return assertValue{}, err
+	}
+	if !p.accept(")") {
+		return assertValue{}, fmt.Errorf("missing ) after %s(", name)
+	}
+	switch name {
+	case "verified_within_days":
+		if argument.number == nil {
+			return assertValue{}, fmt.Errorf("verified_within_days needs a number of days")
+		}
+		return boolValue(verifiedWithin(p.dir, time.Duration(*argument.number*24)*time.Hour)), nil
+	default:
+		return assertValue{}, fmt.Errorf("unknown function %q", name)
+	}
+}
+
+// verifiedWithin checks the audit log for a clean verification of dir
+// newer than the window.
+func verifiedWithin(dir string, window time.Duration) bool {
+	entries, err := audit.ReadAll()
+	if err != nil {
+		return false
+	}
+	cutoff := time.Now().Add(-window)
+	for _, entry := range entries {
+		if entry.Time.Before(cutoff) || entry.Target != dir || entry.Result != "ok" {
+			continue
+		}
+		if strings.Contains(entry.Op, "verify") || strings.Contains(entry.Op, "spotcheck") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+
+	"github.com/leveleven/smtool/internal/audit"
+	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+var (
+	atxID        string
+	atxNode      string
+	atxTickSize  uint64
+	atxPoetTicks uint64
+	atxPoetProof string
+
+	atxCommitmentWriteTo string
+	atxCommitmentForce   bool
+)
+
+var atxCmd = &cobra.Command{
+	Use:   "atx",
+	Short: "inspect ATXs known to a running node",
+}
+
+var atxWeightCmd = &cobra.Command{
+	Use:   "weight",
+	Short: "recompute an ATX's expected weight and compare with the node",
+	Long: `Recompute the expected ticks and weight of an ATX from its numUnits
+and the PoET proof's leaf count (weight = numUnits * ticks, ticks =
+leaves / tick-size), then fetch the node's view of the same ATX and
+explain any discrepancy — the usual suspects when rewards look lower
+than the plot size suggests.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := atxWeight(cmd); err != nil {
+			fail(err)
+		}
+	},
+}
+
+var atxCommitmentCmd = &cobra.Command{
+	Use:   "commitment",
+	Short: "fetch the commitment ATX a new plot should use",
+	Long: `Ask the node for the highest-seen ATX, the same selection it applies
+when choosing a commitment ATX for a fresh identity, and print its ID.
+With --write-to the ID is written into that plot's metadata; a plot
+whose label files already exist is refused, because labels are computed
+over the commitment and would all be invalidated.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := atxCommitment(cmd); err != nil {
+			fail(err)
+		}
+	},
+}
+
+func init() {
+	atxCmd.PersistentFlags().StringVar(&atxNode, "node", "localhost:9092",
+		"gRPC endpoint of the node public API")
+	atxWeightCmd.Flags().StringVar(&atxID, "id", "", "ATX ID as hex (required)")
+	atxWeightCmd.Flags().Uint64Var(&atxTickSize, "tick-size", 9331200,
+		"PoET leaves per tick (network parameter)")
+	atxWeightCmd.Flags().Uint64Var(&atxPoetTicks, "poet-ticks", 0,
+		"tick count from the PoET round, if known")
+	atxWeightCmd.Flags().StringVar(&atxPoetProof, "poet-proof", "",
+		"PoET proof file to derive the tick count from its leaf count")
+	atxWeightCmd.MarkFlagRequired("id")
+	atxCommitmentCmd.Flags().StringVar(&atxCommitmentWriteTo, "write-to", "",
+		"plot directory whose metadata receives the commitment ATX ID")
+	atxCommitmentCmd.Flags().BoolVar(&atxCommitmentForce, "force", false,
+		"overwrite an existing commitment even though label files exist")
+	atxCmd.AddCommand(atxWeightCmd, atxCommitmentCmd)
+	rootCmd.AddCommand(atxCmd)
+}
+
+func atxCommitment(cmd *cobra.Command) error {
+	conn, err := grpc.NewClient(atxNode, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	resp, err := pb.NewActivationServiceClient(conn).Highest(cmd.Context(), &emptypb.Empty{})
+	if err != nil {
+		return err
+	}
+	id := resp.GetAtx().GetId().GetId()
+	if len(id) != 32 {
+		return fmt.Errorf("node returned no usable highest ATX; on a fresh network use the golden ATX from the network config")
+	}
+	fmt.Printf("%x\n", id)
+
+	if atxCommitmentWriteTo == "" {
+		return nil
+	}
+	dir, err := resolvePath(atxCommitmentWriteTo)
+	if err != nil {
+		return err
+	}
+	metadata, err := loadMetadata(dir)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(metadata.CommitmentAtxId, id) {
+		if labels, _ := filepath.Glob(filepath.Join(dir, "postdata_*.bin")); len(labels) > 0 && !atxCommitmentForce {
+			return fmt.Errorf(
+				"%s already has label files computed over commitment %x; changing it invalidates them all (--force to proceed)",
+				dir, metadata.CommitmentAtxId)
+		}
+	}
+	metadata.CommitmentAtxId = id
+	err = saveMetadata(dir, metadata)
+	audit.Record("atx commitment write", dir, map[string]string{"id": fmt.Sprintf("%x", id)}, err)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("commitment ATX written to %s\n", dir)
+	return nil
+}
+
+func atxWeight(cmd *cobra.Command) error {
+	id, err := hex.DecodeString(atxID)
+	if err != nil || len(id) != 32 {
+		return fmt.Errorf("--id must be 32 bytes of hex")
+	}
+	ticks, err := expectedTicks()
+	if err != nil {
+		return err
+	}
+
+	conn, err := grpc.NewClient(atxNode, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	resp, err := pb.NewActivationServiceClient(conn).Get(cmd.Context(),
+		&pb.GetRequest{Id: id})
+	if err != nil {
+		return fmt.Errorf("node does not know ATX %s: %w", atxID, err)
+	}
+	atx := resp.GetAtx()
+	numUnits := uint64(atx.GetNumUnits())
+
+	fmt.Printf("ATX %x\n", id)
+	fmt.Printf("numUnits (node):  %d\n", numUnits)
+	if ticks == 0 {
+		fmt.Println("ticks:            unknown; pass --poet-ticks or --poet-proof for the weight calculation")
+		return nil
+	}
+	expected := numUnits * ticks
+	fmt.Printf("expected ticks:   %d\n", ticks)
+	fmt.Printf("expected weight:  %d (numUnits * ticks)\n", expected)
+
+	// The node's public API does not expose the effective weight
+	// directly, so the comparison runs on its inputs instead.
+	var notes []string
+	if dir, err := resolveQuietly(); err == nil {
+		if metadata, err := loadMetadata(dir); err == nil && uint64(metadata.NumUnits) != numUnits {
+			notes = append(notes, fmt.Sprintf(
+				"local plot has %d units but the ATX commits %d: the ATX predates a resize, and rewards follow the ATX",
+				metadata.NumUnits, numUnits))
+		}
+	}
+	if atx.GetSequence() == 0 {
+		notes = append(notes,
+			"sequence 0: a first ATX earns no rewards for its target epoch, only eligibility for the next")
+	}
+	if len(notes) == 0 {
+		fmt.Println("no discrepancy found; lower-than-expected rewards usually mean higher total network weight this epoch")
+	}
+	for _, note := range notes {
+		fmt.Printf("note: %s\n", note)
+	}
+	return nil
+}
+
+// expectedTicks derives the tick count from --poet-ticks or a PoET
+// proof's leaf count; ticks are whole, so a partial tick earns nothing.
+func expectedTicks() (uint64, error) {
+	if atxPoetTicks != 0 {
+		return atxPoetTicks, nil
+	}
+	if atxPoetProof == "" {
+		return 0, nil
+	}
+	data, err := read(atxPoetProof)
+	if err != nil {
+		return 0, err
+	}
+	var message types.PoetProofMessage
+	if err := codec.Decode(data, &message); err == nil {
+		return message.LeafCount / atxTickSize, nil
+	}
+	var proof types.PoetProof
+	if err := codec.Decode(data, &proof); err != nil {
+		return 0, fmt.Errorf("%s is not a PoET proof: %w", atxPoetProof, err)
+	}
+	return proof.LeafCount / atxTickSize, nil
+}
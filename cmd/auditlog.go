@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/leveleven/smtool/internal/audit"
+	"github.com/leveleven/smtool/internal/export"
+	"github.com/leveleven/smtool/internal/when"
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditLogOp    string
+	auditLogSince string
+	auditLogOut   string
+)
+
+var auditLogCmd = &cobra.Command{
+	Use:   "audit-log",
+	Short: "query the append-only log of mutating operations",
+	Run: func(cmd *cobra.Command, args []string) {
+		entries, err := audit.ReadAll()
+		if err != nil {
+			fail(err)
+			return
+		}
+		cutoff, err := when.ParseSince(auditLogSince)
+		if err != nil {
+			fail(err)
+			return
+		}
+		table := &export.Table{Columns: []export.Column{
+			{Name: "time", Kind: export.Timestamp},
+			{Name: "user", Kind: export.String},
+			{Name: "op", Kind: export.String},
+			{Name: "target", Kind: export.String},
+			{Name: "result", Kind: export.String},
+		}}
+		for _, entry := range entries {
+			if auditLogOp != "" && entry.Op != auditLogOp {
+				continue
+			}
+			if entry.Time.Before(cutoff) {
+				continue
+			}
+			if auditLogOut != "" {
+				table.Append(entry.Time, entry.User, entry.Op, entry.Target, entry.Result)
+				continue
+			}
+			fmt.Printf("%s %s %s target=%s result=%s %v\n",
+				when.Format(entry.Time), entry.User, entry.Op, entry.Target, entry.Result, entry.Params)
+		}
+		if auditLogOut != "" {
+			if err := export.Write(auditLogOut, table); err != nil {
+				fail(err)
+				return
+			}
+			fmt.Printf("%d entries written to %s\n", len(table.Rows), auditLogOut)
+		}
+	},
+}
+
+func init() {
+	auditLogCmd.Flags().StringVar(&auditLogOp, "op", "", "only show entries for this operation")
+	auditLogCmd.Flags().StringVar(&auditLogSince, "since", "",
+		"only show entries newer than this (e.g. \"past 7d\", \"last 2 epochs\", \"72h\" or a date)")
+	auditLogCmd.Flags().StringVar(&auditLogOut, "out", "",
+		"export matching entries to this .csv or .parquet file instead of printing")
+	rootCmd.AddCommand(auditLogCmd)
+}
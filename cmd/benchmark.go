@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/leveleven/smtool/internal/export"
+	"github.com/leveleven/smtool/internal/gpulock"
+	"github.com/leveleven/smtool/internal/resources"
+	"github.com/leveleven/smtool/postrs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	benchmarkProviders []int
+	benchmarkPositions uint64
+	benchmarkOut       string
+)
+
+var benchmarkCmd = &cobra.Command{
+	Use:   "benchmark",
+	Short: "measure label throughput per compute provider",
+	Long: `Run each provider (or the selected ones) through a fixed number of
+label computations and report labels/sec, so the fastest provider ID is
+known before committing to a multi-day init or nonce search.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runBenchmark()
+	},
+}
+
+func init() {
+	benchmarkCmd.Flags().IntSliceVar(&benchmarkProviders, "provider", nil,
+		"provider IDs to benchmark; repeat or comma-separate (default: CPU plus every enumerated provider)")
+	benchmarkCmd.Flags().Uint64Var(&benchmarkPositions, "positions", 1<<20,
+		"labels to compute per provider")
+	benchmarkCmd.Flags().StringVar(&benchmarkOut, "out", "",
+		"also write results to this .csv or .parquet file")
+	rootCmd.AddCommand(benchmarkCmd)
+}
+
+// benchmarkBatchSize keeps individual oracle calls short enough that a
+// slow provider still reports within a reasonable time.
+const benchmarkBatchSize = 64 * 1024
+
+func runBenchmark() {
+	providers := benchmarkProviders
+	if len(providers) == 0 {
+		providers = []int{postrs.CPUProviderID}
+		if enumerated, err := postrs.Providers(""); err == nil {
+			for _, provider := range enumerated {
+				providers = append(providers, int(provider.ID))
+			}
+		}
+	}
+	models := providerModels()
+
+	table := &export.Table{Columns: []export.Column{
+		{Name: "provider", Kind: export.Int},
+		{Name: "model", Kind: export.String},
+		{Name: "labels", Kind: export.Int},
+		{Name: "seconds", Kind: export.Float},
+		{Name: "labelsPerSec", Kind: export.Float},
+	}}
+
+	fmt.Printf("%-8s %-40s %15s\n", "ID", "MODEL", "LABELS/SEC")
+	for _, provider := range providers {
+		elapsed, err := benchmarkProvider(provider)
+		model := models[provider]
+		if err != nil {
+			fmt.Printf("%-8d %-40s %15v\n", provider, model, err)
+			continue
+		}
+		rate := float64(benchmarkPositions) / elapsed.Seconds()
+		fmt.Printf("%-8d %-40s %15.0f\n", provider, model, rate)
+		table.Append(int64(provider), model, int64(benchmarkPositions), elapsed.Seconds(), rate)
+	}
+
+	if benchmarkOut != "" {
+		if err := export.Write(benchmarkOut, table); err != nil {
+			fail(err)
+			return
+		}
+		fmt.Printf("results written to %s\n", benchmarkOut)
+	}
+}
+
+// providerModels maps provider IDs to device names for the report.
+func providerModels() map[int]string {
+	models := map[int]string{postrs.CPUProviderID: "libpost scrypt implementation"}
+	if enumerated, err := postrs.Providers(""); err == nil {
+		for _, provider := range enumerated {
+			models[int(provider.ID)] = provider.Model
+		}
+	}
+	return models
+}
+
+// benchmarkProvider times the configured number of label computations
+// on one provider.
+func benchmarkProvider(provider int) (time.Duration, error) {
+	releaseSlot, err := resources.AcquireGPU(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	defer releaseSlot()
+	releaseDevice, err := gpulock.Acquire(fmt.Sprint(provider), true)
+	if err != nil {
+		return 0, err
+	}
+	defer releaseDevice()
+
+	wo, err := postrs.NewOracle(
+		postrs.WithProviderID(provider),
+		postrs.WithCommitment(bytes.Repeat([]byte{0x5a}, 32)),
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer wo.Close()
+
+	start := time.Now()
+	for position := uint64(0); position < benchmarkPositions; position += benchmarkBatchSize {
+		end := position + benchmarkBatchSize - 1
+		if end >= benchmarkPositions {
+			end = benchmarkPositions - 1
+		}
+		if _, err := wo.Positions(position, end); err != nil {
+			return 0, err
+		}
+	}
+	return time.Since(start), nil
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/leveleven/smtool/internal/postformat"
+)
+
+// bestCandidateFileName is the sidecar file holding the closest-to-
+// target label seen by a nonce search that did not find a nonce.
+const bestCandidateFileName = "best_candidate.json"
+
+// bestCandidate mirrors the official initializer's best-nonce
+// bookkeeping: the label closest to the difficulty target seen so far.
+// Bounded searches (--max-positions) persist it so a later run can make
+// an informed decision or resume.
+type bestCandidate struct {
+	Position uint64 `json:"position"`
+	Label    []byte `json:"label"`
+
+	// format supplies the label size used to walk batches.
+	format postformat.Format
+}
+
+// observe scans a batch of labels starting at position start and keeps
+// the smallest label seen.
+func (b *bestCandidate) observe(start uint64, labels []byte) {
+	size := int(b.format.LabelSize)
+	for i := 0; i+size <= len(labels); i += size {
+		label := labels[i : i+size]
+		if b.Label == nil || bytes.Compare(label, b.Label) < 0 {
+			b.Position = start + uint64(i/size)
+			b.Label = bytes.Clone(label)
+		}
+	}
+}
+
+// save writes the candidate to the sidecar file in the plot directory.
+func (b *bestCandidate) save(dir string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, bestCandidateFileName), data, 0o644)
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/leveleven/smtool/internal/audit"
+	"github.com/leveleven/smtool/internal/cas"
+	"github.com/spf13/cobra"
+)
+
+var cacheGCTTL time.Duration
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "inspect the local content-addressed chain object cache",
+}
+
+var cacheLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "list cached chain objects",
+	Run: func(cmd *cobra.Command, args []string) {
+		entries, err := cas.List()
+		if err != nil {
+			fail(err)
+			return
+		}
+		if len(entries) == 0 {
+			fmt.Println("cache is empty")
+			return
+		}
+		var total int64
+		for _, entry := range entries {
+			total += entry.Size
+			fmt.Printf("%-10s %s %8s  last used %s ago\n",
+				entry.Kind, entry.Key, formatBytes(uint64(entry.Size)), entry.Age.Round(time.Minute))
+		}
+		fmt.Printf("%d objects, %s\n", len(entries), formatBytes(uint64(total)))
+	},
+}
+
+var cacheGCCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "remove cached objects unused for longer than --ttl",
+	Run: func(cmd *cobra.Command, args []string) {
+		removed, freed, err := cas.GC(cacheGCTTL)
+		audit.Record("cache gc", "", map[string]string{"ttl": cacheGCTTL.String()}, err)
+		if err != nil {
+			fail(err)
+			return
+		}
+		fmt.Printf("removed %d objects, freed %s\n", removed, formatBytes(uint64(freed)))
+	},
+}
+
+func init() {
+	cacheGCCmd.Flags().DurationVar(&cacheGCTTL, "ttl", 30*24*time.Hour,
+		"remove objects unused for longer than this")
+	cacheCmd.AddCommand(cacheLsCmd, cacheGCCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+
+	"github.com/leveleven/smtool/internal/cpufeat"
+	"github.com/leveleven/smtool/internal/snapshot"
+	"github.com/leveleven/smtool/postrs"
+	"github.com/spf13/cobra"
+)
+
+// capabilitiesDoc is the machine-readable description of what this
+// smtool build can do on this host, so orchestration layers can adapt
+// to heterogeneous fleets.
+type capabilitiesDoc struct {
+	Version string            `json:"version"`
+	OS      string            `json:"os"`
+	Arch    string            `json:"arch"`
+	Libpost libpostCapability `json:"libpost"`
+	CPU     cpufeat.Report    `json:"cpu"`
+	// CPUWarnings carries the performance warnings derived from the CPU
+	// report, so orchestration does not have to re-derive them.
+	CPUWarnings []string `json:"cpuWarnings,omitempty"`
+	// Features lists subsystems and whether they are usable here.
+	Features map[string]bool `json:"features"`
+}
+
+type libpostCapability struct {
+	Available bool   `json:"available"`
+	Version   string `json:"version,omitempty"`
+	Error     string `json:"error,omitempty"`
+	// Missing lists feature groups the linked library predates, so a
+	// degraded-but-working install is distinguishable from a broken one.
+	Missing []string `json:"missing,omitempty"`
+}
+
+// featureCommands maps libpost feature groups to the smtool commands
+// they back, for degradation reports here and in doctor.
+var featureCommands = map[string]string{
+	postrs.FeatureVerification: "verifyPost, verifyproof, replay",
+	postrs.FeatureProving:      "prove, sweep",
+	postrs.FeatureProviders:    "providers, benchmark, fingerprint",
+}
+
+// missingLibpostFeatures probes the library and returns one entry per
+// missing feature group (the first missing symbol of each).
+func missingLibpostFeatures(path string) map[string]postrs.MissingFeature {
+	missing, err := postrs.MissingFeatures(path)
+	if err != nil || len(missing) == 0 {
+		return nil
+	}
+	byFeature := make(map[string]postrs.MissingFeature)
+	for _, m := range missing {
+		if _, seen := byFeature[m.Feature]; !seen {
+			byFeature[m.Feature] = m
+		}
+	}
+	return byFeature
+}
+
+var capabilitiesCmd = &cobra.Command{
+	Use:   "capabilities",
+	Short: "print a JSON document describing what this build can do here",
+	Run: func(cmd *cobra.Command, args []string) {
+		cpu := cpufeat.Detect()
+		doc := capabilitiesDoc{
+			Version:     Version,
+			OS:          runtime.GOOS,
+			Arch:        runtime.GOARCH,
+			CPU:         cpu,
+			CPUWarnings: cpu.Warnings(),
+			Features: map[string]bool{
+				"serve":       true,
+				"transfer":    true,
+				"pipeline":    true,
+				"tracing":     true,
+				"audit":       true,
+				"snapshots":   false,
+				"nonceSearch": false,
+				"verify":      false,
+			},
+		}
+
+		if version, err := postrs.Available(""); err == nil {
+			doc.Libpost = libpostCapability{Available: true, Version: version}
+			doc.Features["nonceSearch"] = true
+			doc.Features["verify"] = true
+			doc.Features["prove"] = true
+			for feature := range missingLibpostFeatures("") {
+				doc.Libpost.Missing = append(doc.Libpost.Missing, feature)
+				switch feature {
+				case postrs.FeatureVerification:
+					doc.Features["verify"] = false
+				case postrs.FeatureProving:
+					doc.Features["prove"] = false
+				}
+			}
+		} else {
+			doc.Libpost = libpostCapability{Error: err.Error()}
+		}
+		// Snapshot support depends on where the data lives; report it
+		// for the discovered default directory.
+		if dir, err := resolveQuietly(); err == nil {
+			doc.Features["snapshots"] = snapshot.Supported(dir)
+		}
+
+		encoded, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			fail(err)
+			return
+		}
+		fmt.Println(string(encoded))
+	},
+}
+
+// resolveQuietly finds a default data directory without prompting.
+func resolveQuietly() (string, error) {
+	was := autoPath
+	autoPath = true
+	defer func() { autoPath = was }()
+	return resolvePath("")
+}
+
+func init() {
+	rootCmd.AddCommand(capabilitiesCmd)
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"os"
+
+	"github.com/leveleven/smtool/internal/audit"
+	"github.com/spf13/cobra"
+)
+
+var checksumCmd = &cobra.Command{
+	Use:   "checksum",
+	Short: "compute, verify or repair the CRC64 footer on .bin files",
+	Long: `Work with the trailing CRC64 checksum go-spacemesh appends to its
+.bin files (post.bin, challenge.bin, nipost_*.bin). verify and compute
+are read-only; append and strip rewrite the file, restoring a footer
+lost during copying or removing one for tools that expect the bare
+payload.`,
+}
+
+var checksumComputeCmd = &cobra.Command{
+	Use:   "compute <file>",
+	Short: "print the CRC64 of the file's payload",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		file, err := os.Open(args[0])
+		if err != nil {
+			fail(err)
+			return
+		}
+		defer file.Close()
+		info, err := file.Stat()
+		if err != nil {
+			fail(err)
+			return
+		}
+		sum, err := checksumStream(file, info.Size())
+		if err != nil {
+			fail(err)
+			return
+		}
+		fmt.Printf("%016x\n", sum)
+	},
+}
+
+var checksumVerifyCmd = &cobra.Command{
+	Use:   "verify <file>",
+	Short: "check the file's trailing checksum against its payload",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		file, err := os.Open(args[0])
+		if err != nil {
+			fail(err)
+			return
+		}
+		defer file.Close()
+		info, err := file.Stat()
+		if err != nil {
+			fail(err)
+			return
+		}
+		if info.Size() < crc64.Size {
+			fmt.Printf("%s: too short to contain a checksum\n", args[0])
+			return
+		}
+		payloadLen := info.Size() - crc64.Size
+		computed, err := checksumStream(file, payloadLen)
+		if err != nil {
+			fail(err)
+			return
+		}
+		footer := make([]byte, crc64.Size)
+		if _, err := io.ReadFull(file, footer); err != nil {
+			fail(err)
+			return
+		}
+		saved := binary.BigEndian.Uint64(footer)
+		if computed == saved {
+			fmt.Printf("checksum ok (%016x, %s payload)\n", saved, formatBytes(uint64(payloadLen)))
+			return
+		}
+		fail(fmt.Errorf("%s: %w: footer %016x, payload computes to %016x", args[0], ErrChecksum, saved, computed))
+		fmt.Println("if the payload is known-good, repair with: smtool checksum strip + append")
+	},
+}
+
+var checksumAppendCmd = &cobra.Command{
+	Use:   "append <file>",
+	Short: "append a CRC64 footer over the file's current content",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := checksumRewrite(args[0], func(data []byte) ([]byte, error) {
+			footer := make([]byte, crc64.Size)
+			binary.BigEndian.PutUint64(footer, crc64.Checksum(data, crcTable))
+			return append(data, footer...), nil
+		})
+		audit.Record("checksum append", args[0], nil, err)
+		if err != nil {
+			fail(err)
+			return
+		}
+		fmt.Printf("footer appended to %s\n", args[0])
+	},
+}
+
+var checksumStripCmd = &cobra.Command{
+	Use:   "strip <file>",
+	Short: "remove the trailing 8-byte checksum footer",
+	Long: `Remove the last 8 bytes of the file. The footer is not validated
+first: strip exists precisely for files whose footer is wrong, and
+"verify" is the command that judges it.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := checksumRewrite(args[0], func(data []byte) ([]byte, error) {
+			if len(data) < crc64.Size {
+				return nil, fmt.Errorf("%s: too short to contain a checksum", args[0])
+			}
+			return data[:len(data)-crc64.Size], nil
+		})
+		audit.Record("checksum strip", args[0], nil, err)
+		if err != nil {
+			fail(err)
+			return
+		}
+		fmt.Printf("footer stripped from %s\n", args[0])
+	},
+}
+
+func init() {
+	checksumCmd.AddCommand(checksumComputeCmd, checksumVerifyCmd, checksumAppendCmd, checksumStripCmd)
+	rootCmd.AddCommand(checksumCmd)
+}
+
+// checksumRewrite applies transform to the file's content and writes the
+// result back under the original mode, keeping the original as .bak so a
+// wrong append/strip is reversible.
+func checksumRewrite(path string, transform func([]byte) ([]byte, error)) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	transformed, err := transform(data)
+	if err != nil {
+		return err
+	}
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode()
+	}
+	if err := os.WriteFile(path+".bak", data, mode); err != nil {
+		return err
+	}
+	return os.WriteFile(path, transformed, mode)
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/leveleven/smtool/internal/audit"
+	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+var (
+	coinbaseEndpoints []string
+	coinbaseAddress   string
+	coinbaseDryRun    bool
+)
+
+var coinbaseCmd = &cobra.Command{
+	Use:   "coinbase",
+	Short: "show or update smesher coinbases across identities in one pass",
+	Long: `Query every listed smesher endpoint for its current coinbase and,
+when --coinbase is given, update them all in one operation. Multi-
+identity setups run one smesher service per identity; repeat --grpc
+for each. --dry-run previews the changes without applying anything:
+
+  smtool coinbase --grpc node1:9093 --grpc node2:9093 --coinbase sm1... --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		updateCoinbases(cmd.Context())
+	},
+}
+
+func init() {
+	coinbaseCmd.Flags().StringArrayVar(&coinbaseEndpoints, "grpc", nil,
+		"smesher gRPC endpoint; repeat for each identity (e.g. localhost:9093)")
+	coinbaseCmd.Flags().StringVar(&coinbaseAddress, "coinbase", "",
+		"new coinbase address; omit to only show the current ones")
+	coinbaseCmd.Flags().BoolVar(&coinbaseDryRun, "dry-run", false,
+		"preview the updates without applying them")
+	coinbaseCmd.MarkFlagRequired("grpc")
+	rootCmd.AddCommand(coinbaseCmd)
+}
+
+func updateCoinbases(ctx context.Context) {
+	if coinbaseAddress != "" {
+		if _, err := types.StringToAddress(coinbaseAddress); err != nil {
+			fail(fmt.Errorf("invalid --coinbase: %w", err))
+			return
+		}
+	}
+
+	applied, failed := 0, 0
+	for _, endpoint := range coinbaseEndpoints {
+		current, err := queryCoinbase(ctx, endpoint)
+		if err != nil {
+			fmt.Printf("%s: %v\n", endpoint, err)
+			failed++
+			continue
+		}
+		switch {
+		case coinbaseAddress == "":
+			fmt.Printf("%s: %s\n", endpoint, current)
+		case current == coinbaseAddress:
+			fmt.Printf("%s: already %s\n", endpoint, current)
+		case coinbaseDryRun:
+			fmt.Printf("%s: %s -> %s (dry run)\n", endpoint, current, coinbaseAddress)
+		default:
+			err := setCoinbase(ctx, endpoint, coinbaseAddress)
+			audit.Record("coinbase set", endpoint, map[string]string{
+				"from": current,
+				"to":   coinbaseAddress,
+			}, err)
+			if err != nil {
+				fmt.Printf("%s: %v\n", endpoint, err)
+				failed++
+				continue
+			}
+			fmt.Printf("%s: %s -> %s\n", endpoint, current, coinbaseAddress)
+			applied++
+		}
+	}
+	if coinbaseAddress != "" && !coinbaseDryRun {
+		fmt.Printf("%d updated, %d failed of %d endpoints\n", applied, failed, len(coinbaseEndpoints))
+	}
+}
+
+func smesherClient(endpoint string) (pb.SmesherServiceClient, *grpc.ClientConn, error) {
+	conn, err := grpc.NewClient(endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, nil, err
+	}
+	return pb.NewSmesherServiceClient(conn), conn, nil
+}
+
+func queryCoinbase(ctx context.Context, endpoint string) (string, error) {
+	client, conn, err := smesherClient(endpoint)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	resp, err := client.Coinbase(ctx, &emptypb.Empty{})
+	if err != nil {
+		return "", err
+	}
+	return resp.GetAccountId().GetAddress(), nil
+}
+
+func setCoinbase(ctx context.Context, endpoint, address string) error {
+	client, conn, err := smesherClient(endpoint)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = client.SetCoinbase(ctx, &pb.SetCoinbaseRequest{
+		Id: &pb.AccountId{Address: address},
+	})
+	return err
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// configFileName is looked up in the user's home directory; SMTOOL_CONFIG
+// overrides the location for farms that keep it elsewhere.
+const configFileName = ".smtool.yaml"
+
+// applyConfigLayer fills flags the command line left untouched from
+// SMTOOL_* environment variables first and ~/.smtool.yaml second, so
+// the precedence is CLI > environment > file. Keys use the flag name:
+//
+//	auto: true
+//	max-write-mbps: 40
+//	grpc: node7:9092
+//
+// The environment form upper-cases the name and swaps dashes for
+// underscores: SMTOOL_MAX_WRITE_MBPS=40.
+func applyConfigLayer(sets ...*pflag.FlagSet) error {
+	fileValues, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+	var applyErr error
+	for _, set := range sets {
+		set := set
+		set.VisitAll(func(flag *pflag.Flag) {
+			if flag.Changed || applyErr != nil {
+				return
+			}
+			if value, ok := os.LookupEnv(envName(flag.Name)); ok {
+				if err := set.Set(flag.Name, value); err != nil {
+					applyErr = fmt.Errorf("%s: %w", envName(flag.Name), err)
+				}
+				return
+			}
+			if value, ok := fileValues[flag.Name]; ok {
+				if err := set.Set(flag.Name, value); err != nil {
+					applyErr = fmt.Errorf("%s: %s: %w", configFileName, flag.Name, err)
+				}
+			}
+		})
+	}
+	return applyErr
+}
+
+// envName maps a flag name to its SMTOOL_* environment variable.
+func envName(flag string) string {
+	return "SMTOOL_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(flag))
+}
+
+// loadConfigFile reads the config file into flag-settable strings. A
+// missing file is not an error; smtool works flag-only by default.
+func loadConfigFile() (map[string]string, error) {
+	path := os.Getenv("SMTOOL_CONFIG")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, nil
+		}
+		path = filepath.Join(home, configFileName)
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	raw := map[string]any{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		values[key] = flagString(value)
+	}
+	return values, nil
+}
+
+// flagString renders a YAML value the way pflag parses it; lists become
+// the comma-separated form slice flags accept.
+func flagString(value any) string {
+	if list, ok := value.([]any); ok {
+		parts := make([]string, len(list))
+		for i, item := range list {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, ",")
+	}
+	return fmt.Sprint(value)
+}
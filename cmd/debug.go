@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+	_ "net/http/pprof"
+)
+
+var debugAddr string
+
+// jobLabelsScanned is exported through expvar so a live job's progress
+// can be read off the debug endpoint.
+var jobLabelsScanned = expvar.NewInt("smtool.labels_scanned")
+
+// startDebugServer serves net/http/pprof and expvar on addr. It is used
+// to profile live long-running jobs (e.g. a genonce that slowed down
+// 20 hours in) without restarting them.
+func startDebugServer(addr string) {
+	go func() {
+		// pprof and expvar register themselves on the default mux.
+		if err := http.ListenAndServe(addr, nil); err != nil {
+			fmt.Printf("debug server: %v\n", err)
+		}
+	}()
+	fmt.Printf("debug server listening on http://%s/debug/pprof/\n", addr)
+}
@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/leveleven/smtool/internal/audit"
+	"github.com/spf13/cobra"
+)
+
+// delegationFileName is the per-plot revocation list.
+const revocationsFileName = "revocations.json"
+
+// delegationGrant is a time-boxed, signed permission for a third party
+// to run proving against a plot over the remote post service protocol,
+// without ever holding the identity key. The signature is made with the
+// plot's identity key and covers the canonical JSON encoding of
+// everything except the Signature field, mirroring freeze manifests.
+type delegationGrant struct {
+	Version int    `json:"version"`
+	ID      string `json:"id"`
+	NodeID  string `json:"nodeId"`
+	// Delegate is the hex ed25519 public key of the party allowed to
+	// prove.
+	Delegate  string    `json:"delegate"`
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+	Signature string    `json:"signature,omitempty"`
+}
+
+// revocationList is the signed set of revoked grant IDs kept next to
+// the plot.
+type revocationList struct {
+	Revoked   []string `json:"revoked"`
+	Signature string   `json:"signature,omitempty"`
+}
+
+var (
+	delegatePath string
+	delegateKey  string
+	delegateTo   string
+	delegateTTL  time.Duration
+	delegateOut  string
+)
+
+var delegateCmd = &cobra.Command{
+	Use:   "delegate",
+	Short: "manage time-boxed proving delegations for a plot",
+	Long: `Issue, verify and revoke signed delegation grants that let a hosting
+provider prove against a plot for a limited time without holding the
+identity key. Grants are signed with the plot's identity key; verifiers
+check the signature, the validity window and the plot's revocation
+list.`,
+}
+
+var delegateGrantCmd = &cobra.Command{
+	Use:   "grant",
+	Short: "issue a time-limited delegation to a third-party key",
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := resolvePath(delegatePath)
+		if err != nil {
+			fail(err)
+			return
+		}
+		err = grantDelegation(dir, delegateTo, delegateTTL, delegateOut)
+		audit.Record("delegate grant", dir, map[string]string{
+			"delegate": delegateTo, "ttl": delegateTTL.String(),
+		}, err)
+		if err != nil {
+			fail(err)
+		}
+	},
+}
+
+var delegateVerifyCmd = &cobra.Command{
+	Use:   "verify <grant.json>",
+	Short: "check a grant's signature, window and revocation status",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := resolvePath(delegatePath)
+		if err != nil {
+			fail(err)
+			return
+		}
+		if err := verifyDelegation(dir, args[0]); err != nil {
+			fail(err)
+			return
+		}
+		fmt.Println("grant is valid")
+	},
+}
+
+var delegateRevokeCmd = &cobra.Command{
+	Use:   "revoke <grant.json>",
+	Short: "add a grant to the plot's signed revocation list",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := resolvePath(delegatePath)
+		if err != nil {
+			fail(err)
+			return
+		}
+		err = revokeDelegation(dir, args[0])
+		audit.Record("delegate revoke", dir, map[string]string{"grant": args[0]}, err)
+		if err != nil {
+			fail(err)
+		}
+	},
+}
+
+func init() {
+	delegateCmd.PersistentFlags().StringVar(&delegatePath, "path", "",
+		"plot directory (default: discovered data directory)")
+	delegateCmd.PersistentFlags().StringVar(&delegateKey, "key", "",
+		"identity key file (default: identity.key in the plot directory)")
+	delegateGrantCmd.Flags().StringVar(&delegateTo, "delegate", "",
+		"hex ed25519 public key of the party allowed to prove")
+	delegateGrantCmd.Flags().DurationVar(&delegateTTL, "ttl", 30*24*time.Hour,
+		"how long the grant stays valid")
+	delegateGrantCmd.Flags().StringVar(&delegateOut, "out", "delegation.json", "grant output path")
+	delegateGrantCmd.MarkFlagRequired("delegate")
+	delegateCmd.AddCommand(delegateGrantCmd, delegateVerifyCmd, delegateRevokeCmd)
+	rootCmd.AddCommand(delegateCmd)
+}
+
+// identityKey loads the plot's ed25519 identity seed. Unlike signing
+// keys for freeze manifests it is never auto-generated: a missing
+// identity is an error, not an invitation to mint one.
+func identityKey(dir string) (ed25519.PrivateKey, error) {
+	path := delegateKey
+	if path == "" {
+		path = filepath.Join(dir, "identity.key")
+	}
+	seed, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("identity key: %w", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("%s: want %d-byte ed25519 seed, got %d bytes", path, ed25519.SeedSize, len(seed))
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// signable returns the canonical bytes the signature covers.
+func (g delegationGrant) signable() ([]byte, error) {
+	g.Signature = ""
+	return json.Marshal(g)
+}
+
+func grantDelegation(dir, delegate string, ttl time.Duration, out string) error {
+	if raw, err := hex.DecodeString(delegate); err != nil || len(raw) != ed25519.PublicKeySize {
+		return fmt.Errorf("--delegate must be a hex %d-byte ed25519 public key", ed25519.PublicKeySize)
+	}
+	key, err := identityKey(dir)
+	if err != nil {
+		return err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	grant := delegationGrant{
+		Version:   1,
+		ID:        hex.EncodeToString(id),
+		NodeID:    hex.EncodeToString(key.Public().(ed25519.PublicKey)),
+		Delegate:  delegate,
+		NotBefore: now,
+		NotAfter:  now.Add(ttl),
+	}
+	payload, err := grant.signable()
+	if err != nil {
+		return err
+	}
+	grant.Signature = hex.EncodeToString(ed25519.Sign(key, payload))
+
+	data, err := json.MarshalIndent(grant, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(out, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("grant %s written to %s, valid until %s\n", grant.ID, out, grant.NotAfter.Format(time.RFC3339))
+	return nil
+}
+
+func loadGrant(path string) (*delegationGrant, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	grant := new(delegationGrant)
+	if err := json.Unmarshal(data, grant); err != nil {
+		return nil, fmt.Errorf("parse grant: %w", err)
+	}
+	return grant, nil
+}
+
+func verifyDelegation(dir, grantPath string) error {
+	grant, err := loadGrant(grantPath)
+	if err != nil {
+		return err
+	}
+	nodeID, err := hex.DecodeString(grant.NodeID)
+	if err != nil || len(nodeID) != ed25519.PublicKeySize {
+		return fmt.Errorf("grant has an invalid node ID")
+	}
+	signature, err := hex.DecodeString(grant.Signature)
+	if err != nil {
+		return fmt.Errorf("grant has an invalid signature encoding")
+	}
+	payload, err := grant.signable()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(ed25519.PublicKey(nodeID), payload, signature) {
+		return fmt.Errorf("signature verification failed")
+	}
+	now := time.Now()
+	if now.Before(grant.NotBefore) {
+		return fmt.Errorf("grant is not valid before %s", grant.NotBefore.Format(time.RFC3339))
+	}
+	if now.After(grant.NotAfter) {
+		return fmt.Errorf("grant expired at %s", grant.NotAfter.Format(time.RFC3339))
+	}
+	if metadata, err := loadMetadata(dir); err == nil {
+		if hex.EncodeToString(metadata.NodeId) != grant.NodeID {
+			return fmt.Errorf("grant was issued for a different identity than this plot")
+		}
+	}
+	revoked, err := loadRevocations(dir)
+	if err != nil {
+		return err
+	}
+	for _, id := range revoked.Revoked {
+		if id == grant.ID {
+			return fmt.Errorf("grant %s has been revoked", grant.ID)
+		}
+	}
+	return nil
+}
+
+func loadRevocations(dir string) (*revocationList, error) {
+	data, err := os.ReadFile(filepath.Join(dir, revocationsFileName))
+	if os.IsNotExist(err) {
+		return &revocationList{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	list := new(revocationList)
+	if err := json.Unmarshal(data, list); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", revocationsFileName, err)
+	}
+	return list, nil
+}
+
+func revokeDelegation(dir, grantPath string) error {
+	grant, err := loadGrant(grantPath)
+	if err != nil {
+		return err
+	}
+	key, err := identityKey(dir)
+	if err != nil {
+		return err
+	}
+	list, err := loadRevocations(dir)
+	if err != nil {
+		return err
+	}
+	for _, id := range list.Revoked {
+		if id == grant.ID {
+			fmt.Printf("grant %s is already revoked\n", grant.ID)
+			return nil
+		}
+	}
+	list.Revoked = append(list.Revoked, grant.ID)
+	list.Signature = ""
+	payload, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	list.Signature = hex.EncodeToString(ed25519.Sign(key, payload))
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, revocationsFileName), data, 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("grant %s revoked\n", grant.ID)
+	return nil
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/leveleven/smtool/internal/cpufeat"
+	"github.com/leveleven/smtool/postrs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	doctorPath     string
+	doctorSkipScan bool
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "run health checks against a plot and the local environment",
+	Long: `Run a battery of health checks: metadata consistency, libpost
+availability, CPU feature warnings, and a zero-region scan of the label
+files. Zero runs and filesystem holes inside postdata files are the
+signature of failed GPU plotting; affected label ranges are printed so
+they can be repaired without re-initializing the whole plot.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := resolvePath(doctorPath)
+		if err != nil {
+			fail(err)
+			return
+		}
+		runDoctor(dir)
+	},
+}
+
+func init() {
+	doctorCmd.Flags().StringVar(&doctorPath, "path", "",
+		"plot directory containing postdata_metadata.json (default: discovered data directory)")
+	doctorCmd.Flags().BoolVar(&doctorSkipScan, "skip-scan", false,
+		"skip the zero-region scan of the label files (reads every file)")
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// runDoctor executes every check and prints one line per finding. Checks
+// are independent: a failed one never stops the rest.
+func runDoctor(dir string) {
+	problems := 0
+	report := func(ok bool, format string, args ...any) {
+		status := "ok  "
+		if !ok {
+			status = "FAIL"
+			problems++
+		}
+		fmt.Printf("[%s] %s\n", status, fmt.Sprintf(format, args...))
+	}
+
+	metadata, err := loadMetadata(dir)
+	if err != nil {
+		report(false, "metadata: %v", err)
+		return
+	}
+	report(true, "metadata: loaded from %s", dir)
+	for _, problem := range validateMetadata(metadata) {
+		report(false, "metadata: %v", problem)
+	}
+	if metadata.Nonce == nil {
+		report(false, "nonce: not found yet; run smtool genonce")
+	} else {
+		report(true, "nonce: %d", *metadata.Nonce)
+	}
+
+	if version, err := postrs.Available(""); err == nil {
+		report(true, "libpost: %s", version)
+		for feature, missing := range missingLibpostFeatures("") {
+			report(false, "libpost: no %s (disables %s); needs libpost >= %s",
+				feature, featureCommands[feature], missing.Since)
+		}
+	} else {
+		report(false, "libpost: %v", err)
+	}
+
+	for _, warning := range cpufeat.Detect().Warnings() {
+		report(false, "cpu: %s", warning)
+	}
+
+	if !doctorSkipScan {
+		regions, err := scanZeroRegions(dir, metadata)
+		if err != nil {
+			report(false, "zero scan: %v", err)
+		} else if len(regions) == 0 {
+			report(true, "zero scan: no zero regions or holes in the label files")
+		}
+		for _, region := range regions {
+			kind := "zero run"
+			if region.Hole {
+				kind = "hole"
+			}
+			report(false, "zero scan: %s in %s covering labels %d-%d",
+				kind, region.File, region.FirstLabel, region.LastLabel)
+		}
+	}
+
+	if problems == 0 {
+		fmt.Println("all checks passed")
+	} else {
+		fmt.Printf("%d problem(s) found\n", problems)
+	}
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/leveleven/smtool/postrs"
+)
+
+// Sentinel error kinds. Commands wrap these with %w at the point of
+// failure so automation can tell failure classes apart by exit status
+// instead of parsing output.
+var (
+	ErrMetadataMissing = errors.New("metadata missing")
+	ErrNonceExists     = errors.New("nonce already exists")
+	ErrChecksum        = errors.New("checksum mismatch")
+)
+
+// Exit statuses, one per error kind; unclassified failures exit 1.
+const (
+	exitOK              = 0
+	exitFailure         = 1
+	exitMetadataMissing = 2
+	exitNonceExists     = 3
+	exitProvider        = 4
+	exitChecksum        = 5
+)
+
+// exitStatus is what Execute exits with; fail raises it.
+var exitStatus int
+
+// fail reports an error on stderr and records the exit status. The
+// first classified failure wins: a later generic error never downgrades
+// a specific status, and a specific one upgrades a generic.
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	code := exitFailure
+	switch {
+	case errors.Is(err, ErrMetadataMissing):
+		code = exitMetadataMissing
+	case errors.Is(err, ErrNonceExists):
+		code = exitNonceExists
+	case errors.Is(err, postrs.ErrProvider):
+		code = exitProvider
+	case errors.Is(err, ErrChecksum):
+		code = exitChecksum
+	}
+	if exitStatus == exitOK || (exitStatus == exitFailure && code != exitFailure) {
+		exitStatus = code
+	}
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain <error text or log snippet>",
+	Short: "explain a spacemesh/post error and suggest the command that fixes it",
+	Long: `Match an error message, log snippet or error code against the curated
+knowledge base and print what it means, the likely cause, and the
+smtool command that diagnoses or fixes it. Paste the text straight from
+the node log or a support ticket:
+
+  smtool explain "validation failed: invalid pow"`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		explain(strings.Join(args, " "))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+}
+
+// explainRules extends the logscan ruleset with error strings that show
+// up in support tickets rather than logs: verifier codes, init errors,
+// and the mistakes people make with the tools themselves.
+var explainRules = []logRule{
+	{
+		name:    "invalid pow",
+		pattern: regexp.MustCompile(`(?i)invalid (k2)?pow`),
+		explain: "the proof's proof-of-work does not meet the network difficulty; usually a k2pow miscomputation or RandomX running without enough memory",
+		suggest: "smtool hugepages  (and re-generate the proof)",
+	},
+	{
+		name:    "invalid index",
+		pattern: regexp.MustCompile(`(?i)invalid (index|indices|label)`),
+		explain: "one or more labels referenced by the proof do not match the commitment; the plot data is wrong at those positions",
+		suggest: "smtool verifyPost --path <plot dir>  then  smtool verifyData --path <plot dir>",
+	},
+	{
+		name:    "commitment mismatch",
+		pattern: regexp.MustCompile(`(?i)(commitment|atx).{0,20}(mismatch|differ|wrong)`),
+		explain: "the plot was initialized for a different CommitmentATX or identity than the node is using",
+		suggest: "smtool identity check --node-dir <node dir> --grpc <node>:9093",
+	},
+	{
+		name:    "different identity",
+		pattern: regexp.MustCompile(`(?i)(node ?id|smesher ?id|identity).{0,30}(mismatch|differ|does not match|unknown)`),
+		explain: "the plot metadata carries another identity's NodeId; the node will never accept its proofs",
+		suggest: "smtool identity check --node-dir <node dir>",
+	},
+	{
+		name:    "zero or sparse label data",
+		pattern: regexp.MustCompile(`(?i)(all zeros|zero(ed)? (labels?|data)|sparse)`),
+		explain: "regions of the postdata files hold zeros instead of labels, the signature of failed GPU plotting",
+		suggest: "smtool doctor --path <plot dir>  then  smtool fingerprint test --provider <id>",
+	},
+	{
+		name:    "libpost missing",
+		pattern: regexp.MustCompile(`(?i)(dlopen|libpost|missing symbol)`),
+		explain: "the libpost shared library is absent, incompatible, or built without the needed entry points",
+		suggest: "smtool capabilities  (shows which libpost was found and what it supports)",
+	},
+	{
+		name:    "checksum mismatch",
+		pattern: regexp.MustCompile(`(?i)(crc|checksum).{0,20}(mismatch|fail|invalid)`),
+		explain: "a .bin file's CRC64 trailer does not match its content; the file was truncated or bit-rotted",
+		suggest: "smtool parsePost --skip-checksum <file>  to inspect it anyway; restore the file from a replica",
+	},
+	{
+		name:    "disk full",
+		pattern: regexp.MustCompile(`(?i)(no space left|disk (is )?full|enospc)`),
+		explain: "the volume ran out of space mid-write; partially written plot files are likely",
+		suggest: "free space, then  smtool doctor --path <plot dir>",
+	},
+}
+
+func explain(snippet string) {
+	matched := 0
+	for _, rule := range append(append([]logRule{}, explainRules...), logRules...) {
+		if !rule.pattern.MatchString(snippet) {
+			continue
+		}
+		matched++
+		fmt.Printf("%s\n", rule.name)
+		fmt.Printf("  what it means: %s\n", rule.explain)
+		fmt.Printf("  investigate:   %s\n\n", rule.suggest)
+	}
+	if matched == 0 {
+		fmt.Println("no matching entry in the knowledge base")
+		fmt.Println("try: smtool logscan --file <node.log>  for whole-log diagnosis")
+		fmt.Println("or:  smtool doctor --path <plot dir>   for plot health checks")
+	}
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/leveleven/smtool/internal/audit"
+	"github.com/leveleven/smtool/internal/fingerprint"
+	"github.com/leveleven/smtool/internal/when"
+	"github.com/leveleven/smtool/postrs"
+	"github.com/spf13/cobra"
+)
+
+var fingerprintProvider int
+
+var fingerprintCmd = &cobra.Command{
+	Use:   "fingerprint",
+	Short: "track which compute providers produce valid labels on this host",
+}
+
+var fingerprintTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "run the known-answer test against a provider and record the result",
+	Long: `Compute a batch of labels on the chosen provider and compare it with
+the CPU reference implementation. The verdict is recorded per device
+model and libpost version, and plotting commands refuse a provider with
+a failed test unless forced.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		key, err := providerFingerprint(fingerprintProvider)
+		if err != nil {
+			fail(err)
+			return
+		}
+		status, err := fingerprint.KnownAnswerTest(fingerprintProvider, key)
+		audit.Record("fingerprint test", key, map[string]string{
+			"provider": fmt.Sprint(fingerprintProvider),
+		}, err)
+		if err != nil {
+			fail(err)
+			return
+		}
+		if status.Valid {
+			fmt.Printf("%s: valid\n", key)
+		} else {
+			fmt.Printf("%s: INVALID (%s)\n", key, status.Detail)
+		}
+	},
+}
+
+var fingerprintLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "list recorded provider test results",
+	Run: func(cmd *cobra.Command, args []string) {
+		statuses, err := fingerprint.List()
+		if err != nil {
+			fail(err)
+			return
+		}
+		if len(statuses) == 0 {
+			fmt.Println("no providers tested yet; run smtool fingerprint test")
+			return
+		}
+		for _, status := range statuses {
+			verdict := "valid"
+			if !status.Valid {
+				verdict = "INVALID: " + status.Detail
+			}
+			fmt.Printf("%-50s %s (checked %s)\n", status.Fingerprint, verdict, when.Format(status.CheckedAt))
+		}
+	},
+}
+
+func init() {
+	fingerprintTestCmd.Flags().IntVar(&fingerprintProvider, "provider", postrs.CPUProviderID,
+		"compute provider ID to test (see smtool providers)")
+	fingerprintCmd.AddCommand(fingerprintTestCmd)
+	fingerprintCmd.AddCommand(fingerprintLsCmd)
+	rootCmd.AddCommand(fingerprintCmd)
+}
+
+// providerFingerprint builds the registry key for a provider ID from
+// the device model and the loaded libpost version.
+func providerFingerprint(providerID int) (string, error) {
+	version, err := postrs.Available("")
+	if err != nil {
+		return "", err
+	}
+	model := "libpost scrypt implementation"
+	if providerID != postrs.CPUProviderID {
+		providers, err := postrs.Providers("")
+		if err != nil {
+			return "", err
+		}
+		found := false
+		for _, provider := range providers {
+			if int(provider.ID) == providerID {
+				model = provider.Model
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", fmt.Errorf("provider %d not found; see smtool providers", providerID)
+		}
+	}
+	return fingerprint.Key(model, version), nil
+}
+
+// checkProviderFingerprint refuses a provider that previously failed
+// the known-answer test on this host, unless force is set. An untested
+// provider or an unreadable registry never blocks: the check exists to
+// stop repeating a known-bad configuration, not to gate new ones.
+func checkProviderFingerprint(providerID int, force bool) error {
+	key, err := providerFingerprint(providerID)
+	if err != nil {
+		return nil
+	}
+	status, err := fingerprint.Lookup(key)
+	if err != nil || status == nil || status.Valid {
+		return nil
+	}
+	if force {
+		fmt.Printf("warning: %s failed its known-answer test (%s); proceeding because of --force\n",
+			key, status.Detail)
+		return nil
+	}
+	return fmt.Errorf("%s produced invalid labels on this host (%s, checked %s); "+
+		"re-test with smtool fingerprint test or override with --force",
+		key, status.Detail, when.Format(status.CheckedAt))
+}
@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// freezeManifest is a signed, timestamped attestation that a plot
+// existed in a given state at a given time, suitable for legal or
+// insurance purposes. The signature covers the canonical JSON encoding
+// of everything except the Signature field itself.
+type freezeManifest struct {
+	Version   int          `json:"version"`
+	CreatedAt time.Time    `json:"createdAt"`
+	NodeID    string       `json:"nodeId,omitempty"`
+	Host      freezeHost   `json:"host"`
+	Files     []freezeFile `json:"files"`
+	PublicKey string       `json:"publicKey"`
+	Signature string       `json:"signature,omitempty"`
+}
+
+type freezeHost struct {
+	Hostname string `json:"hostname"`
+	OS       string `json:"os"`
+	Arch     string `json:"arch"`
+}
+
+type freezeFile struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+var (
+	freezePath string
+	freezeKey  string
+	freezeOut  string
+)
+
+var freezeCmd = &cobra.Command{
+	Use:   "freeze",
+	Short: "create or verify a signed attestation of a plot's exact state",
+}
+
+var freezeCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "digest every file in the plot and sign the manifest",
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := resolvePath(freezePath)
+		if err != nil {
+			fail(err)
+			return
+		}
+		if err := createFreezeManifest(dir, freezeKey, freezeOut); err != nil {
+			fail(err)
+			return
+		}
+		fmt.Printf("manifest written to %s\n", freezeOut)
+	},
+}
+
+var freezeVerifyCmd = &cobra.Command{
+	Use:   "verify <manifest.json>",
+	Short: "verify a manifest's signature and re-check the file digests",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := resolvePath(freezePath)
+		if err != nil {
+			fail(err)
+			return
+		}
+		if err := verifyFreezeManifest(dir, args[0]); err != nil {
+			fail(err)
+			return
+		}
+		fmt.Println("manifest is valid and matches the plot")
+	},
+}
+
+func init() {
+	freezeCmd.PersistentFlags().StringVar(&freezePath, "path", "",
+		"plot directory (default: discovered data directory)")
+	freezeCreateCmd.Flags().StringVar(&freezeKey, "key", "freeze.key",
+		"ed25519 signing key file; created if it does not exist")
+	freezeCreateCmd.Flags().StringVar(&freezeOut, "out", "freeze-manifest.json", "manifest output path")
+	freezeCmd.AddCommand(freezeCreateCmd, freezeVerifyCmd)
+	rootCmd.AddCommand(freezeCmd)
+}
+
+// signingKey loads the ed25519 seed at path, generating one on first
+// use.
+func signingKey(path string) (ed25519.PrivateKey, error) {
+	seed, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		seed = make([]byte, ed25519.SeedSize)
+		if _, err := rand.Read(seed); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, seed, 0o600); err != nil {
+			return nil, err
+		}
+		fmt.Printf("generated new signing key at %s\n", path)
+	} else if err != nil {
+		return nil, err
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("%s: want %d-byte ed25519 seed, got %d bytes", path, ed25519.SeedSize, len(seed))
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// digestFiles hashes every regular file under dir (except key material).
+func digestFiles(dir string) ([]freezeFile, error) {
+	var files []freezeFile
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		if bundleExcluded(info.Name()) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		hash := sha256.New()
+		if _, err := io.Copy(hash, file); err != nil {
+			return err
+		}
+		files = append(files, freezeFile{
+			Name:   filepath.ToSlash(rel),
+			Size:   info.Size(),
+			SHA256: hex.EncodeToString(hash.Sum(nil)),
+		})
+		return nil
+	})
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, err
+}
+
+// signable returns the canonical bytes the signature covers.
+func (m freezeManifest) signable() ([]byte, error) {
+	m.Signature = ""
+	return json.Marshal(m)
+}
+
+func createFreezeManifest(dir, keyPath, out string) error {
+	key, err := signingKey(keyPath)
+	if err != nil {
+		return err
+	}
+	files, err := digestFiles(dir)
+	if err != nil {
+		return err
+	}
+	hostname, _ := os.Hostname()
+	manifest := freezeManifest{
+		Version:   1,
+		CreatedAt: time.Now().UTC(),
+		Host:      freezeHost{Hostname: hostname, OS: runtime.GOOS, Arch: runtime.GOARCH},
+		Files:     files,
+		PublicKey: hex.EncodeToString(key.Public().(ed25519.PublicKey)),
+	}
+	if metadata, err := loadMetadata(dir); err == nil {
+		manifest.NodeID = hex.EncodeToString(metadata.NodeId)
+	}
+
+	payload, err := manifest.signable()
+	if err != nil {
+		return err
+	}
+	manifest.Signature = hex.EncodeToString(ed25519.Sign(key, payload))
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(out, data, 0o644)
+}
+
+func verifyFreezeManifest(dir, manifestPath string) error {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return err
+	}
+	var manifest freezeManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return err
+	}
+
+	publicKey, err := hex.DecodeString(manifest.PublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("manifest has an invalid public key")
+	}
+	signature, err := hex.DecodeString(manifest.Signature)
+	if err != nil {
+		return fmt.Errorf("manifest has an invalid signature encoding")
+	}
+	payload, err := manifest.signable()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(ed25519.PublicKey(publicKey), payload, signature) {
+		return fmt.Errorf("signature verification failed")
+	}
+
+	current, err := digestFiles(dir)
+	if err != nil {
+		return err
+	}
+	currentByName := make(map[string]freezeFile, len(current))
+	for _, f := range current {
+		currentByName[f.Name] = f
+	}
+	for _, want := range manifest.Files {
+		got, ok := currentByName[want.Name]
+		if !ok {
+			return fmt.Errorf("%s: missing from plot", want.Name)
+		}
+		if got.Size != want.Size || got.SHA256 != want.SHA256 {
+			return fmt.Errorf("%s: contents differ from manifest", want.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	"github.com/leveleven/smtool/internal/postformat"
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/post/shared"
+	"github.com/spf13/cobra"
+)
+
+var (
+	fixturesOut           string
+	fixturesSeed          int64
+	fixturesNumUnits      uint32
+	fixturesLabelsPerUnit uint64
+)
+
+// genFixturesCmd is a maintainer tool: it produces a tiny plot
+// directory (metadata, label files, post.bin, challenge.bin) that is
+// deterministic in the seed, so tests can exercise real decode paths
+// without shipping large binary fixtures. The label data is pseudo-
+// random stand-in data, not real oracle output.
+var genFixturesCmd = &cobra.Command{
+	Use:    "gen-fixtures",
+	Short:  "generate a tiny deterministic plot directory for tests",
+	Hidden: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := generateFixtures(fixturesOut, fixturesSeed, fixturesNumUnits, fixturesLabelsPerUnit); err != nil {
+			fail(err)
+			return
+		}
+		fmt.Printf("fixtures written to %s\n", fixturesOut)
+	},
+}
+
+func init() {
+	genFixturesCmd.Flags().StringVar(&fixturesOut, "out", "fixtures", "output directory")
+	genFixturesCmd.Flags().Int64Var(&fixturesSeed, "seed", 1, "deterministic seed")
+	genFixturesCmd.Flags().Uint32Var(&fixturesNumUnits, "numUnits", 2, "units in the fixture plot")
+	genFixturesCmd.Flags().Uint64Var(&fixturesLabelsPerUnit, "labelsPerUnit", 4096, "labels per unit")
+	rootCmd.AddCommand(genFixturesCmd)
+}
+
+func generateFixtures(dir string, seed int64, numUnits uint32, labelsPerUnit uint64) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	deterministic := func(n int) []byte {
+		buf := make([]byte, n)
+		rng.Read(buf)
+		return buf
+	}
+
+	metadata := &shared.PostMetadata{
+		NodeId:          deterministic(32),
+		CommitmentAtxId: deterministic(32),
+		NumUnits:        numUnits,
+		LabelsPerUnit:   labelsPerUnit,
+		MaxFileSize:     uint64(numUnits) * labelsPerUnit * postformat.Current.LabelSize,
+	}
+	if err := saveMetadata(dir, metadata); err != nil {
+		return err
+	}
+
+	labels := deterministic(int(uint64(numUnits) * labelsPerUnit * postformat.Current.LabelSize))
+	if err := writeLabelFile(filepath.Join(dir, "postdata_0.bin"), labels); err != nil {
+		return err
+	}
+
+	if err := write(filepath.Join(dir, "challenge.bin"), deterministic(32)); err != nil {
+		return err
+	}
+
+	post := &types.Post{
+		Nonce:   rng.Uint32(),
+		Indices: deterministic(64),
+		Pow:     rng.Uint64(),
+	}
+	encoded, err := codec.Encode(post)
+	if err != nil {
+		return err
+	}
+	return write(filepath.Join(dir, "post.bin"), encoded)
+}
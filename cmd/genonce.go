@@ -0,0 +1,516 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/leveleven/smtool/internal/audit"
+	"github.com/leveleven/smtool/internal/gpulock"
+	"github.com/leveleven/smtool/internal/metrics"
+	"github.com/leveleven/smtool/internal/postformat"
+	"github.com/leveleven/smtool/internal/resources"
+	"github.com/leveleven/smtool/internal/tracing"
+	"github.com/leveleven/smtool/internal/usage"
+	"github.com/leveleven/smtool/internal/workers"
+	"github.com/leveleven/smtool/postrs"
+	"github.com/spacemeshos/post/shared"
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	genoncePath         string
+	genonceMaxPositions uint64
+	genonceDifficulty   string
+	genonceWaitGPU      bool
+	genonceRestart      bool
+	genonceProviders    []int
+	genonceAllProviders bool
+	genonceForce        bool
+	genonceProgress     time.Duration
+	genonceCheckpoint   uint64
+	genonceBatchSize    uint64
+	genonceWorkers      int
+)
+
+var genonceCmd = &cobra.Command{
+	Use:   "genonce",
+	Short: "search a VRF nonce for an initialized plot",
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := resolvePath(genoncePath)
+		if err != nil {
+			fail(err)
+			return
+		}
+		generateNonce(cmd.Context(), dir)
+	},
+}
+
+func init() {
+	genonceCmd.Flags().StringVar(&genoncePath, "path", "",
+		"plot directory containing postdata_metadata.json (default: discovered data directory)")
+	genonceCmd.Flags().Uint64Var(&genonceMaxPositions, "max-positions", 0,
+		"stop after scanning this many positions (0 = scan the whole plot)")
+	genonceCmd.Flags().StringVar(&genonceDifficulty, "difficulty", "",
+		"hex-encoded 32-byte VRF difficulty override (e.g. a node-supplied value)")
+	genonceCmd.Flags().BoolVar(&genonceWaitGPU, "wait-gpu", true,
+		"queue behind other jobs holding the device lock instead of failing")
+	genonceCmd.Flags().BoolVar(&genonceRestart, "restart", false,
+		"ignore the saved LastPosition and search from label 0")
+	genonceCmd.Flags().IntSliceVar(&genonceProviders, "provider", []int{postrs.CPUProviderID},
+		"compute provider ID to search with; repeat to shard across devices (see smtool providers)")
+	genonceCmd.Flags().BoolVar(&genonceAllProviders, "all-providers", false,
+		"shard the search across every GPU provider libpost enumerates")
+	genonceCmd.Flags().BoolVar(&genonceForce, "force", false,
+		"use providers even if they previously failed their known-answer test")
+	genonceCmd.Flags().DurationVar(&genonceProgress, "progress-interval", 30*time.Second,
+		"how often to print a progress line with throughput and ETA (0 disables)")
+	genonceCmd.Flags().Uint64Var(&genonceCheckpoint, "checkpoint-interval", 64,
+		"persist LastPosition every this many completed batches, so a crash costs at most one interval (0 saves only at exit)")
+	genonceCmd.Flags().Uint64Var(&genonceBatchSize, "batch-size", nonceBatchSize,
+		"labels per oracle call; larger batches amortize dispatch overhead, smaller ones checkpoint finer")
+	genonceCmd.Flags().IntVar(&genonceWorkers, "workers", 1,
+		"concurrent oracle pipelines per provider, keeping the device busy between batches")
+	rootCmd.AddCommand(genonceCmd)
+}
+
+// nonceBatchSize is the default number of positions requested from the
+// oracle per call; with several providers or workers it is also the
+// sharding unit. Overridden by --batch-size.
+const nonceBatchSize = 128 * 1024
+
+// searchProviders resolves the provider list for this run.
+func searchProviders() ([]int, error) {
+	if !genonceAllProviders {
+		return genonceProviders, nil
+	}
+	enumerated, err := postrs.Providers("")
+	if err != nil {
+		return nil, err
+	}
+	var ids []int
+	for _, provider := range enumerated {
+		if provider.Class == postrs.ClassGPU {
+			ids = append(ids, int(provider.ID))
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("--all-providers: no GPU providers found; see smtool providers")
+	}
+	return ids, nil
+}
+
+// batchTracker hands out batch indices to provider workers and keeps a
+// contiguous completion frontier, so LastPosition only ever records
+// positions with no unscanned gap before them and a resumed search
+// misses nothing.
+type batchTracker struct {
+	next atomic.Uint64
+
+	mu       sync.Mutex
+	done     map[uint64]bool
+	frontier uint64
+}
+
+// claim returns the next unclaimed batch index.
+func (t *batchTracker) claim() uint64 {
+	return t.next.Add(1) - 1
+}
+
+// complete marks a batch done and returns the new frontier: the number
+// of leading batches fully scanned.
+func (t *batchTracker) complete(batch uint64) uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.done[batch] = true
+	for t.done[t.frontier] {
+		delete(t.done, t.frontier)
+		t.frontier++
+	}
+	return t.frontier
+}
+
+// generateNonce scans the plot's label space for a position whose label
+// beats the VRF difficulty and stores it in the metadata. The range is
+// sharded in batches across one oracle per provider; the first worker
+// to find a nonce stops the rest. The search stops early (after saving
+// progress) when ctx expires.
+func generateNonce(ctx context.Context, dir string) {
+	metadata, err := loadMetadata(dir)
+	if err != nil {
+		fail(err)
+		return
+	}
+	for _, problem := range validateMetadata(metadata) {
+		fmt.Printf("metadata warning: %v\n", problem)
+	}
+	providers, err := searchProviders()
+	if err != nil {
+		fail(err)
+		return
+	}
+	if genonceBatchSize == 0 || genonceWorkers < 1 {
+		fmt.Println("--batch-size and --workers must be positive")
+		return
+	}
+	if metadata.Nonce != nil {
+		// Plots initialized by older tools carry the position but not
+		// the label; backfill it so the node accepts the metadata
+		// without re-deriving anything. The repair is this run's useful
+		// work, so it exits clean; only a plot with nothing left to do
+		// reports ErrNonceExists.
+		if len(metadata.NonceValue) == 0 {
+			if err := backfillNonceValue(dir, metadata, providers[0]); err != nil {
+				fail(err)
+			}
+			return
+		}
+		fail(fmt.Errorf("%w: %d", ErrNonceExists, *metadata.Nonce))
+		return
+	}
+	maybeSnapshot(dir)
+	format := postformat.ForMetadata(metadata)
+
+	var (
+		nonce      atomic.Pointer[uint64]
+		nonceValue atomic.Pointer[[]byte]
+	)
+
+	startTime := time.Now()
+	var scanned atomic.Uint64
+	defer func() {
+		usage.Collect(startTime).Print("labels", scanned.Load())
+	}()
+
+	numLabels := uint64(metadata.NumUnits) * metadata.LabelsPerUnit
+	searchEnd := numLabels
+	if genonceMaxPositions > 0 && genonceMaxPositions < numLabels {
+		searchEnd = genonceMaxPositions
+	}
+
+	// An interrupted search leaves LastPosition behind; pick up there
+	// instead of rescanning everything, unless --restart forces a fresh
+	// pass.
+	searchStart := uint64(0)
+	if genonceRestart {
+		metadata.LastPosition = nil
+	} else if metadata.LastPosition != nil {
+		searchStart = *metadata.LastPosition + 1
+		if searchStart >= searchEnd {
+			fmt.Printf("LastPosition %d already covers the search range; use --restart to search again\n",
+				*metadata.LastPosition)
+			return
+		}
+		fmt.Printf("resuming search at position %d (use --restart to start over)\n", searchStart)
+	}
+
+	// A mismatch between the locally derived difficulty and a
+	// node-supplied one is a subtle source of "node rejects my nonce"
+	// reports, so always print the derived value and any override.
+	difficulty := nonceDifficulty(numLabels)
+	fmt.Printf("difficulty (derived from %d labels): %x\n", numLabels, difficulty)
+	if genonceDifficulty != "" {
+		override, err := hex.DecodeString(genonceDifficulty)
+		if err != nil || len(override) != 32 {
+			fmt.Printf("invalid --difficulty, want 64 hex chars: %v\n", err)
+			return
+		}
+		fmt.Printf("difficulty (override):               %x\n", override)
+		if !bytes.Equal(override, difficulty) {
+			fmt.Println("warning: override differs from the derived difficulty; using the override")
+		}
+		difficulty = override
+	}
+
+	for _, provider := range providers {
+		if err := checkProviderFingerprint(provider, genonceForce); err != nil {
+			fail(err)
+			return
+		}
+	}
+
+	defer func() {
+		if n := nonce.Load(); n != nil {
+			metadata.Nonce = n
+			if v := nonceValue.Load(); v != nil {
+				metadata.NonceValue = *v
+			}
+		}
+		err := saveMetadata(dir, metadata)
+		audit.Record("genonce", dir, map[string]string{
+			"nonceFound": fmt.Sprint(nonce.Load() != nil),
+			"providers":  fmt.Sprint(providers),
+		}, err)
+		if err != nil {
+			fail(err)
+		}
+	}()
+
+	var bestMu sync.Mutex
+	best := &bestCandidate{format: format}
+	defer func() {
+		if nonce.Load() == nil && best.Label != nil {
+			if err := best.save(dir); err != nil {
+				fail(err)
+			} else {
+				fmt.Printf("best candidate: position %d, label %x (saved to %s)\n",
+					best.Position, best.Label, bestCandidateFileName)
+			}
+		}
+	}()
+
+	ctx, stopSearch := context.WithCancel(ctx)
+	defer stopSearch()
+	tracker := &batchTracker{done: map[uint64]bool{}}
+	lastProgress := startTime
+
+	// Checkpointing persists the frontier mid-run; without it a power
+	// loss rewinds the search to the last completed run's LastPosition.
+	// The mutex covers LastPosition itself, not just the save: a
+	// checkpoint marshals the metadata, so the field must not change
+	// mid-save, and workers completing batches out of order must not
+	// rewind it below a frontier already recorded.
+	var checkpointMu sync.Mutex
+	var lastCheckpoint uint64
+	advanceFrontier := func(frontier, last uint64) {
+		checkpointMu.Lock()
+		defer checkpointMu.Unlock()
+		if metadata.LastPosition != nil && last <= *metadata.LastPosition {
+			return
+		}
+		metadata.LastPosition = &last
+		if genonceCheckpoint == 0 || frontier < lastCheckpoint+genonceCheckpoint {
+			return
+		}
+		lastCheckpoint = frontier
+		if err := saveMetadata(dir, metadata); err != nil {
+			fmt.Printf("checkpoint: %v\n", err)
+		}
+	}
+	var progressMu sync.Mutex
+
+	group, ctx := workers.New(ctx)
+	for _, provider := range providers {
+		provider := provider
+		group.Go(fmt.Sprintf("provider %d", provider), func(ctx context.Context) error {
+			return searchWorker(ctx, provider, difficulty, metadata, format, searchStart, searchEnd,
+				tracker, &scanned, &nonce, &nonceValue, stopSearch,
+				func(position uint64, output []byte) {
+					bestMu.Lock()
+					best.observe(position, output)
+					bestMu.Unlock()
+				},
+				func(frontier uint64) {
+					// Only the contiguous frontier advances LastPosition
+					// and the progress line; a fast device running ahead
+					// of a slow one does not skew either.
+					if frontier > 0 {
+						last := searchStart + frontier*genonceBatchSize - 1
+						if last >= searchEnd {
+							last = searchEnd - 1
+						}
+						advanceFrontier(frontier, last)
+					}
+					progressMu.Lock()
+					if genonceProgress > 0 && time.Since(lastProgress) >= genonceProgress {
+						printNonceProgress(startTime, scanned.Load(),
+							frontier*genonceBatchSize, searchEnd-searchStart)
+						lastProgress = time.Now()
+					}
+					progressMu.Unlock()
+				})
+		})
+	}
+	if err := group.Wait(); err != nil && nonce.Load() == nil {
+		fmt.Printf("search stopped: %v\n", err)
+		return
+	}
+	if n := nonce.Load(); n != nil {
+		if v := nonceValue.Load(); v != nil {
+			fmt.Printf("found nonce: %d (value %x)\n", *n, *v)
+		}
+		return
+	}
+	fmt.Println("no nonce found")
+}
+
+// searchWorker takes the device for one provider and runs --workers
+// concurrent search loops on it. One loop per device already keeps a
+// GPU saturated; extra workers overlap oracle calls so the device is
+// never idle between batches, which mostly pays off on the CPU provider
+// and on hosts where the dispatch overhead per call is high.
+func searchWorker(ctx context.Context, provider int, difficulty []byte,
+	metadata *shared.PostMetadata, format postformat.Format,
+	searchStart, searchEnd uint64, tracker *batchTracker, scanned *atomic.Uint64,
+	nonce *atomic.Pointer[uint64], nonceValue *atomic.Pointer[[]byte],
+	stopSearch context.CancelFunc, observe func(uint64, []byte), completed func(uint64),
+) error {
+	releaseSlot, err := resources.AcquireGPU(ctx)
+	if err != nil {
+		return err
+	}
+	defer releaseSlot()
+
+	// Hold the device lock for the duration of the oracles' lives so
+	// the initializers never share the card with another job; the
+	// workers below all run under this one claim.
+	releaseDevice, err := gpulock.Acquire(fmt.Sprint(provider), genonceWaitGPU)
+	if err != nil {
+		return err
+	}
+	defer releaseDevice()
+
+	providerStart := time.Now()
+	var providerScanned atomic.Uint64
+	loop := func(ctx context.Context) error {
+		return searchLoop(ctx, provider, difficulty, metadata, format, searchStart, searchEnd,
+			tracker, scanned, &providerScanned, providerStart,
+			nonce, nonceValue, stopSearch, observe, completed)
+	}
+	if genonceWorkers <= 1 {
+		return loop(ctx)
+	}
+	group, ctx := workers.New(ctx)
+	for i := 0; i < genonceWorkers; i++ {
+		group.Go(fmt.Sprintf("provider %d worker %d", provider, i), loop)
+	}
+	return group.Wait()
+}
+
+// searchLoop is one oracle pipeline: it claims batches from the tracker
+// and computes them until the range is exhausted, a nonce is found
+// anywhere, or ctx expires.
+func searchLoop(ctx context.Context, provider int, difficulty []byte,
+	metadata *shared.PostMetadata, format postformat.Format,
+	searchStart, searchEnd uint64, tracker *batchTracker,
+	scanned, providerScanned *atomic.Uint64, providerStart time.Time,
+	nonce *atomic.Pointer[uint64], nonceValue *atomic.Pointer[[]byte],
+	stopSearch context.CancelFunc, observe func(uint64, []byte), completed func(uint64),
+) error {
+	wo, err := postrs.NewOracle(
+		postrs.WithProviderID(provider),
+		postrs.WithCommitment(postrs.CommitmentBytes(metadata.NodeId, metadata.CommitmentAtxId)),
+		postrs.WithVRFDifficulty(difficulty),
+	)
+	if err != nil {
+		return err
+	}
+	defer wo.Close()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil // another worker found the nonce or the run was stopped
+		}
+		if err := pauseOnBatteryPower(ctx); err != nil {
+			return nil
+		}
+		batch := tracker.claim()
+		start := searchStart + batch*genonceBatchSize
+		if start >= searchEnd || start < searchStart { // wrapped near MaxUint64
+			return nil
+		}
+		end := start + genonceBatchSize - 1
+		if end >= searchEnd || end < start {
+			end = searchEnd - 1
+		}
+		_, span := tracing.Start(ctx, "oracle.Positions",
+			attribute.Int64("start", int64(start)), attribute.Int64("end", int64(end)),
+			attribute.Int("provider", provider))
+		result, err := wo.Positions(start, end)
+		span.End()
+		if err != nil {
+			metrics.Errors.Add(1)
+			stopSearch()
+			return err
+		}
+		if result.Nonce != nil {
+			// Keep the label alongside the position, like the official
+			// initializer does; some node versions require NonceValue in
+			// the metadata.
+			label := format.Label(result.Output, start, *result.Nonce)
+			nonce.Store(result.Nonce)
+			nonceValue.Store(&label)
+			stopSearch()
+			return nil
+		}
+		observe(start, result.Output)
+		scanned.Add(end - start + 1)
+		jobLabelsScanned.Set(int64(scanned.Load()))
+		metrics.LabelsScanned.Add(end - start + 1)
+		total := providerScanned.Add(end - start + 1)
+		if elapsed := time.Since(providerStart).Seconds(); elapsed > 0 {
+			metrics.ProviderRate.Set(fmt.Sprint(provider), float64(total)/elapsed)
+		}
+		completed(tracker.complete(batch))
+	}
+}
+
+// printNonceProgress emits one progress line: labels scanned, rate,
+// share of the search range covered, and the ETA at the current rate.
+func printNonceProgress(startTime time.Time, scanned, covered, total uint64) {
+	elapsed := time.Since(startTime).Seconds()
+	if elapsed <= 0 || scanned == 0 || total == 0 {
+		return
+	}
+	if covered > total {
+		covered = total
+	}
+	rate := float64(scanned) / elapsed
+	remaining := time.Duration(float64(total-covered) / rate * float64(time.Second))
+	fmt.Printf("progress: %d labels scanned, %.0f labels/s, %.1f%% of range, ETA %s\n",
+		scanned, rate, float64(covered)/float64(total)*100, remaining.Round(time.Second))
+}
+
+// backfillNonceValue recomputes the label at the stored nonce position
+// and persists it as NonceValue.
+func backfillNonceValue(dir string, metadata *shared.PostMetadata, provider int) error {
+	wo, err := postrs.NewOracle(
+		postrs.WithProviderID(provider),
+		postrs.WithCommitment(postrs.CommitmentBytes(metadata.NodeId, metadata.CommitmentAtxId)),
+	)
+	if err != nil {
+		return fmt.Errorf("cannot backfill NonceValue: %w", err)
+	}
+	defer wo.Close()
+
+	result, err := wo.Positions(*metadata.Nonce, *metadata.Nonce)
+	if err != nil {
+		return fmt.Errorf("recompute label at nonce %d: %w", *metadata.Nonce, err)
+	}
+	format := postformat.ForMetadata(metadata)
+	metadata.NonceValue = result.Output[:format.LabelSize]
+	err = saveMetadata(dir, metadata)
+	audit.Record("genonce", dir, map[string]string{"backfill": "nonceValue"}, err)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("backfilled NonceValue %x\n", []byte(metadata.NonceValue))
+	return nil
+}
+
+// nonceDifficulty returns the 32-byte VRF difficulty threshold for a
+// plot holding numLabels labels, scaled so that one qualifying label is
+// expected over the whole plot.
+func nonceDifficulty(numLabels uint64) []byte {
+	difficulty := new(big.Int).Lsh(big.NewInt(1), 256)
+	difficulty.Sub(difficulty, big.NewInt(1))
+	difficulty.Div(difficulty, new(big.Int).SetUint64(numLabels))
+	return difficulty.FillBytes(make([]byte, 32))
+}
+
+// nonceBeatsDifficulty reports whether a stored nonce label still
+// qualifies under the difficulty for a plot of numLabels labels. An
+// absent label cannot be checked and counts as failing; the node would
+// reject an unverifiable nonce anyway.
+func nonceBeatsDifficulty(label []byte, numLabels uint64) bool {
+	if len(label) == 0 {
+		return false
+	}
+	return bytes.Compare(label, nonceDifficulty(numLabels)[:len(label)]) < 0
+}
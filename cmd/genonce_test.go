@@ -0,0 +1,33 @@
+package cmd
+
+import "testing"
+
+func TestBatchTrackerClaim(t *testing.T) {
+	tracker := &batchTracker{done: map[uint64]bool{}}
+	for want := uint64(0); want < 3; want++ {
+		if got := tracker.claim(); got != want {
+			t.Errorf("claim() = %d, want %d", got, want)
+		}
+	}
+}
+
+func TestBatchTrackerComplete(t *testing.T) {
+	tracker := &batchTracker{done: map[uint64]bool{}}
+	steps := []struct {
+		batch        uint64
+		wantFrontier uint64
+	}{
+		{batch: 1, wantFrontier: 0}, // gap at 0 holds the frontier back
+		{batch: 0, wantFrontier: 2}, // filling it releases both
+		{batch: 3, wantFrontier: 2},
+		{batch: 2, wantFrontier: 4},
+	}
+	for _, step := range steps {
+		if got := tracker.complete(step.batch); got != step.wantFrontier {
+			t.Errorf("complete(%d) = %d, want %d", step.batch, got, step.wantFrontier)
+		}
+	}
+	if len(tracker.done) != 0 {
+		t.Errorf("done batches behind the frontier were not pruned: %v", tracker.done)
+	}
+}
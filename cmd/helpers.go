@@ -0,0 +1,332 @@
+package cmd
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/leveleven/smtool/internal/datadir"
+	"github.com/leveleven/smtool/internal/pathsafe"
+	"github.com/leveleven/smtool/internal/power"
+	"github.com/leveleven/smtool/internal/resources"
+	"github.com/leveleven/smtool/internal/throttle"
+	"github.com/spacemeshos/post/shared"
+)
+
+const metadataFileName = "postdata_metadata.json"
+
+var crcTable = crc64.MakeTable(crc64.ECMA)
+
+// skipChecksum disables CRC64 verification in read — an escape hatch
+// for recovery scenarios where the trailer is known-bad but the payload
+// is still wanted. Set by the root --skip-checksum flag.
+var skipChecksum bool
+
+// largeReadThreshold is the size above which read switches to the
+// concurrent read/hash path.
+const largeReadThreshold = 64 << 20
+
+// read loads a go-spacemesh state file and verifies the trailing CRC64
+// checksum the node appends to its .bin files, returning the payload
+// without the checksum footer. Large files are read and hashed
+// concurrently.
+func read(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() < crc64.Size {
+		return nil, fmt.Errorf("%s: too short to contain a checksum", path)
+	}
+	if info.Size() >= largeReadThreshold {
+		return readLarge(file, info.Size(), path)
+	}
+
+	data := make([]byte, info.Size())
+	if _, err := io.ReadFull(file, data); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return checkTrailer(data, path)
+}
+
+// readLarge reads the file in chunks while a second goroutine folds the
+// chunks into the running CRC, overlapping disk and CPU time.
+func readLarge(file *os.File, size int64, path string) ([]byte, error) {
+	const chunkSize = 8 << 20
+
+	data := make([]byte, size)
+	chunks := make(chan []byte, 4)
+	crc := make(chan uint64, 1)
+	go func() {
+		var sum uint64
+		for chunk := range chunks {
+			sum = crc64.Update(sum, crcTable, chunk)
+		}
+		crc <- sum
+	}()
+
+	payloadLen := len(data) - crc64.Size
+	for offset := 0; offset < len(data); {
+		end := offset + chunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		if _, err := io.ReadFull(file, data[offset:end]); err != nil {
+			close(chunks)
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		// Only payload bytes count towards the checksum.
+		if offset < payloadLen {
+			hashEnd := end
+			if hashEnd > payloadLen {
+				hashEnd = payloadLen
+			}
+			chunks <- data[offset:hashEnd]
+		}
+		offset = end
+	}
+	close(chunks)
+
+	payload := data[:payloadLen]
+	saved := binary.BigEndian.Uint64(data[payloadLen:])
+	if computed := <-crc; computed != saved && !skipChecksum {
+		return nil, fmt.Errorf("%s: %w: stored %x, computed %x", path, ErrChecksum, saved, computed)
+	}
+	return payload, nil
+}
+
+// checkTrailer splits payload and checksum footer and verifies them.
+func checkTrailer(data []byte, path string) ([]byte, error) {
+	payload := data[:len(data)-crc64.Size]
+	saved := binary.BigEndian.Uint64(data[len(data)-crc64.Size:])
+	if computed := crc64.Checksum(payload, crcTable); computed != saved && !skipChecksum {
+		return nil, fmt.Errorf("%s: %w: stored %x, computed %x", path, ErrChecksum, saved, computed)
+	}
+	return payload, nil
+}
+
+// checksumStream folds the next payloadLen bytes of file into the CRC64
+// through a single reusable chunk buffer, so files of any size can be
+// validated with bounded memory. The buffer counts against the
+// --max-memory budget.
+func checksumStream(file *os.File, payloadLen int64) (uint64, error) {
+	const chunkSize = 8 << 20
+	releaseMemory, err := resources.ReserveMemory(chunkSize)
+	if err != nil {
+		return 0, err
+	}
+	defer releaseMemory()
+
+	buf := make([]byte, chunkSize)
+	var sum uint64
+	for remaining := payloadLen; remaining > 0; {
+		n := int64(chunkSize)
+		if n > remaining {
+			n = remaining
+		}
+		if _, err := io.ReadFull(file, buf[:n]); err != nil {
+			return 0, err
+		}
+		sum = crc64.Update(sum, crcTable, buf[:n])
+		remaining -= n
+	}
+	return sum, nil
+}
+
+// write stores payload at path with the trailing CRC64 checksum that
+// read expects, mirroring how go-spacemesh writes its .bin files.
+func write(path string, payload []byte) error {
+	data := make([]byte, len(payload)+crc64.Size)
+	copy(data, payload)
+	binary.BigEndian.PutUint64(data[len(payload):], crc64.Checksum(payload, crcTable))
+	return os.WriteFile(path, data, 0o644)
+}
+
+// maxWriteMBps caps the bandwidth of bulk writes (label data, archive
+// output). Zero means unpaced. Set by the root --max-write-mbps flag.
+var maxWriteMBps float64
+
+// paceWriter applies the global write bandwidth cap to w.
+func paceWriter(w io.Writer) io.Writer {
+	return throttle.NewWriter(w, maxWriteMBps)
+}
+
+// writeLabelFile writes bulk label data through the global write pacer.
+func writeLabelFile(path string, labels []byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := paceWriter(file).Write(labels); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// pauseOnBattery makes long jobs wait at their next checkpoint while
+// the host runs on battery or UPS power. Set by the root
+// --pause-on-battery flag.
+var pauseOnBattery bool
+
+// pauseOnBatteryPower blocks until mains power returns (when
+// --pause-on-battery is set), announcing the pause once. Progress saved
+// so far stays on disk, so an outage that outlasts the battery costs
+// only the current batch.
+func pauseOnBatteryPower(ctx context.Context) error {
+	if !pauseOnBattery || !power.OnBattery() {
+		return nil
+	}
+	fmt.Println("host is on battery/UPS power; pausing until mains returns")
+	if err := power.WaitForMains(ctx); err != nil {
+		return err
+	}
+	fmt.Println("mains power restored; resuming")
+	return nil
+}
+
+// autoPath makes resolvePath pick the first discovered data directory
+// without prompting. Set by the root --auto flag.
+var autoPath bool
+
+// memoryPlot redirects resolvePath to a synthetic plot generated in a
+// RAM-backed directory, so verification, proving and nonce-search code
+// paths can be exercised in CI and on laptops without touching disks or
+// GPUs. Set by the root --memory-plot flag.
+var memoryPlot bool
+
+// memoryPlotDir holds the per-run synthetic plot location once created.
+var memoryPlotDir string
+
+// makeMemoryPlot generates the synthetic plot on first use. /dev/shm is
+// preferred because it is tmpfs on Linux; elsewhere the OS temp dir is
+// the closest thing available.
+func makeMemoryPlot() (string, error) {
+	if memoryPlotDir != "" {
+		return memoryPlotDir, nil
+	}
+	base := os.TempDir()
+	if info, err := os.Stat("/dev/shm"); err == nil && info.IsDir() {
+		base = "/dev/shm"
+	}
+	dir := filepath.Join(base, fmt.Sprintf("smtool-memplot-%d", os.Getpid()))
+	if err := generateFixtures(dir, 1, 2, 4096); err != nil {
+		return "", fmt.Errorf("generate memory plot: %w", err)
+	}
+	fmt.Printf("using in-memory plot %s\n", dir)
+	memoryPlotDir = dir
+	return dir, nil
+}
+
+// resolvePath returns path unchanged when given; otherwise it discovers
+// the default spacemesh data directories for this OS and either picks
+// the first (--auto) or asks the user to choose.
+func resolvePath(path string) (string, error) {
+	if memoryPlot {
+		return makeMemoryPlot()
+	}
+	if path != "" {
+		return pathsafe.Canonical(path)
+	}
+	candidates := datadir.Candidates()
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no --path given and no default spacemesh data directory found")
+	}
+	if autoPath || len(candidates) == 1 {
+		fmt.Printf("using data directory %s\n", candidates[0])
+		return candidates[0], nil
+	}
+	fmt.Println("no --path given; candidates:")
+	for i, candidate := range candidates {
+		fmt.Printf("  [%d] %s\n", i+1, candidate)
+	}
+	fmt.Print("select directory: ")
+	var choice int
+	if _, err := fmt.Scanln(&choice); err != nil || choice < 1 || choice > len(candidates) {
+		return "", fmt.Errorf("invalid selection")
+	}
+	return candidates[choice-1], nil
+}
+
+// formatBytes renders a byte count with a binary unit suffix.
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// loadMetadata reads postdata_metadata.json from a plot directory.
+func loadMetadata(dir string) (*shared.PostMetadata, error) {
+	data, err := os.ReadFile(filepath.Join(dir, metadataFileName))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s: %w", filepath.Join(dir, metadataFileName), ErrMetadataMissing)
+	}
+	if err != nil {
+		return nil, err
+	}
+	metadata := new(shared.PostMetadata)
+	if err := json.Unmarshal(data, metadata); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", metadataFileName, err)
+	}
+	return metadata, nil
+}
+
+// saveMetadata writes postdata_metadata.json back to a plot directory.
+// The write is atomic (temp file, fsync, rename) so a crash mid-write
+// never leaves a torn metadata file, and the previous version survives
+// as a .bak that "smtool metadata restore" can roll back to.
+func saveMetadata(dir string, metadata *shared.PostMetadata) error {
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, metadataFileName)
+
+	temp, err := os.CreateTemp(dir, metadataFileName+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name()) // no-op after a successful rename
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	// CreateTemp uses 0o600; match the mode the file always had.
+	if err := os.Chmod(temp.Name(), 0o644); err != nil {
+		return err
+	}
+
+	// Keep the previous version; rename is atomic so readers see either
+	// the old file or the new one, never a mix.
+	if _, err := os.Stat(path); err == nil {
+		if err := os.Rename(path, path+".bak"); err != nil {
+			return err
+		}
+	}
+	return os.Rename(temp.Name(), path)
+}
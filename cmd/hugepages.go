@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leveleven/smtool/internal/audit"
+	"github.com/spf13/cobra"
+	"golang.org/x/sys/unix"
+)
+
+var hugepagesApply bool
+
+// randomxDatasetMiB is the RandomX dataset size the k2pow solver maps;
+// the hugepage recommendation covers it plus scratchpad headroom.
+const randomxDatasetMiB = 2336
+
+var hugepagesCmd = &cobra.Command{
+	Use:   "hugepages",
+	Short: "check and tune hugepages for the RandomX k2pow solver",
+	Long: `Report the system's hugepage configuration against what the
+RandomX-based k2pow solver needs, benchmark memory access with and
+without hugepages, and either print the sysctl to apply or apply it
+directly with --apply (requires privileges). Backing the RandomX
+dataset with hugepages roughly halves k2pow solve time on many systems.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := checkHugepages(); err != nil {
+			fail(err)
+		}
+	},
+}
+
+func init() {
+	hugepagesCmd.Flags().BoolVar(&hugepagesApply, "apply", false,
+		"write the recommended hugepage count to /proc/sys/vm/nr_hugepages")
+	rootCmd.AddCommand(hugepagesCmd)
+}
+
+func checkHugepages() error {
+	meminfo, err := os.ReadFile("/proc/meminfo")
+	if err != nil {
+		return fmt.Errorf("hugepage detection needs /proc/meminfo: %w", err)
+	}
+	var total, free, pageKiB int64
+	for _, line := range strings.Split(string(meminfo), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		value, _ := strconv.ParseInt(fields[1], 10, 64)
+		switch fields[0] {
+		case "HugePages_Total:":
+			total = value
+		case "HugePages_Free:":
+			free = value
+		case "Hugepagesize:":
+			pageKiB = value
+		}
+	}
+	if pageKiB == 0 {
+		return fmt.Errorf("kernel reports no hugepage support")
+	}
+
+	needed := (randomxDatasetMiB*1024 + pageKiB - 1) / pageKiB
+	fmt.Printf("hugepage size: %d KiB, configured: %d, free: %d\n", pageKiB, total, free)
+	fmt.Printf("needed for the RandomX dataset: %d pages\n", needed)
+
+	benchmarkHugepages()
+
+	if free >= needed {
+		fmt.Println("hugepages are sufficient for k2pow")
+		return nil
+	}
+	// nr_hugepages is the pool's total size, not a request for more, so
+	// grow the existing pool by the shortfall; writing `needed` on a host
+	// with a larger pool in use would shrink it under its current users.
+	target := total + (needed - free)
+	if !hugepagesApply {
+		fmt.Println("to enable (halves k2pow solve time on many systems):")
+		fmt.Printf("  sudo sysctl -w vm.nr_hugepages=%d\n", target)
+		fmt.Println("or rerun with --apply as root")
+		return nil
+	}
+	err = os.WriteFile("/proc/sys/vm/nr_hugepages", []byte(strconv.FormatInt(target, 10)), 0o644)
+	audit.Record("hugepages", "/proc/sys/vm/nr_hugepages",
+		map[string]string{"pages": strconv.FormatInt(target, 10)}, err)
+	if err != nil {
+		return fmt.Errorf("apply failed (root required, and the pages must be allocatable): %w", err)
+	}
+	fmt.Printf("vm.nr_hugepages set to %d (not persistent across reboots; add it to sysctl.conf)\n", target)
+	return nil
+}
+
+// benchmarkHugepages times RandomX-like random 64-byte reads over a
+// 256 MiB buffer backed by normal pages and, where the kernel allows
+// it, by hugepages, to show the concrete difference on this machine.
+func benchmarkHugepages() {
+	const size = 256 << 20
+	normal, err := unix.Mmap(-1, 0, size, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_PRIVATE|unix.MAP_ANONYMOUS)
+	if err != nil {
+		return
+	}
+	defer unix.Munmap(normal)
+	fmt.Printf("memory benchmark (4K pages):  %s\n", timeRandomReads(normal))
+
+	huge, err := unix.Mmap(-1, 0, size, unix.PROT_READ|unix.PROT_WRITE,
+		unix.MAP_PRIVATE|unix.MAP_ANONYMOUS|unix.MAP_HUGETLB)
+	if err != nil {
+		fmt.Printf("memory benchmark (hugepages): unavailable (%v)\n", err)
+		return
+	}
+	defer unix.Munmap(huge)
+	fmt.Printf("memory benchmark (hugepages): %s\n", timeRandomReads(huge))
+}
+
+// timeRandomReads touches the buffer once to fault it in, then measures
+// a fixed number of random 64-byte reads, the access pattern RandomX's
+// dataset lookups follow.
+func timeRandomReads(buf []byte) string {
+	for i := 0; i < len(buf); i += 4096 {
+		buf[i] = byte(i)
+	}
+	rng := rand.New(rand.NewSource(1))
+	const reads = 1 << 22
+	start := time.Now()
+	var sink byte
+	for i := 0; i < reads; i++ {
+		offset := rng.Intn(len(buf) - 64)
+		for j := 0; j < 64; j += 8 {
+			sink ^= buf[offset+j]
+		}
+	}
+	elapsed := time.Since(start)
+	_ = sink
+	return fmt.Sprintf("%d random reads in %v (%.0f ns/read)",
+		reads, elapsed.Round(time.Millisecond), float64(elapsed.Nanoseconds())/reads)
+}
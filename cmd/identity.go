@@ -0,0 +1,301 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/leveleven/smtool/internal/audit"
+	"github.com/leveleven/smtool/internal/plots"
+	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/pbkdf2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+var (
+	identityCheckKey  string
+	identityCheckNode string
+	identityCheckGRPC string
+
+	identityNewOut   string
+	identityNewForce bool
+
+	identityShowKey string
+
+	identityDeriveSeed     string
+	identityDeriveMnemonic string
+	identityDerivePass     string
+	identityDeriveIndex    uint32
+	identityDeriveOut      string
+)
+
+var identityCmd = &cobra.Command{
+	Use:   "identity",
+	Short: "smeshing identity key operations",
+}
+
+var identityCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "verify key.bin, plot metadata and the running node agree on the identity",
+	Long: `Derive the public key from the node's key.bin and compare it against
+the NodeId in every plot found under the node directory, and (when
+--grpc is given) against the smesher ID reported by the running node.
+"Plot belongs to a different identity" is the classic misconfiguration
+after moving plots or restoring a node from backup, and it only shows
+up as rejected proofs otherwise.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := resolvePath(identityCheckNode)
+		if err != nil {
+			fail(err)
+			return
+		}
+		checkIdentity(cmd, dir)
+	},
+}
+
+var identityNewCmd = &cobra.Command{
+	Use:   "new",
+	Short: "generate a fresh ed25519 smeshing identity",
+	Long: `Generate an ed25519 identity and write it as a go-spacemesh
+compatible key file (hex-encoded private key, mode 0600), printing the
+public NodeID. The key is generated from the OS entropy source; for a
+wallet-recoverable identity use identity derive instead.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, private, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			fail(err)
+			return
+		}
+		if err := writeIdentityKey(identityNewOut, private, identityNewForce); err != nil {
+			fail(err)
+		}
+	},
+}
+
+var identityShowCmd = &cobra.Command{
+	Use:   "show [key-file]",
+	Short: "print the public NodeID of a key file",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		keyPath := identityShowKey
+		if len(args) == 1 {
+			keyPath = args[0]
+		}
+		if keyPath == "" {
+			dir, err := resolvePath("")
+			if err != nil {
+				fail(err)
+				return
+			}
+			keyPath = filepath.Join(dir, "key.bin")
+		}
+		public, err := publicKeyFromFile(keyPath)
+		if err != nil {
+			fail(err)
+			return
+		}
+		fmt.Printf("%x\n", public)
+	},
+}
+
+var identityDeriveCmd = &cobra.Command{
+	Use:   "derive",
+	Short: "derive the smeshing identity from a seed or wallet mnemonic",
+	Long: `Recreate an identity deterministically from a 32-byte hex seed or a
+BIP39 wallet mnemonic. Mnemonics follow the wallet derivation path
+(m/44'/540'/0'/0'/index'), so the identity matches what the wallet
+created; --index selects among multiple accounts. With --out the key is
+written as a node-compatible key file, otherwise only the NodeID is
+printed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := deriveIdentity(); err != nil {
+			fail(err)
+		}
+	},
+}
+
+func init() {
+	identityCheckCmd.Flags().StringVar(&identityCheckNode, "node-dir", "",
+		"node data directory holding key.bin and the plots (default: discovered data directory)")
+	identityCheckCmd.Flags().StringVar(&identityCheckKey, "key", "",
+		"identity key file (default: key.bin in the node directory)")
+	identityCheckCmd.Flags().StringVar(&identityCheckGRPC, "grpc", "",
+		"also compare with the smesher ID of the node listening here, e.g. localhost:9093")
+	identityNewCmd.Flags().StringVar(&identityNewOut, "out", "local.key", "key file to write")
+	identityNewCmd.Flags().BoolVar(&identityNewForce, "force", false,
+		"overwrite an existing key file (the old identity is lost)")
+	identityShowCmd.Flags().StringVar(&identityShowKey, "key", "",
+		"key file to read (default: key.bin in the discovered data directory)")
+	identityDeriveCmd.Flags().StringVar(&identityDeriveSeed, "seed", "",
+		"32-byte hex seed to derive the key from directly")
+	identityDeriveCmd.Flags().StringVar(&identityDeriveMnemonic, "mnemonic", "",
+		"BIP39 wallet mnemonic to derive from")
+	identityDeriveCmd.Flags().StringVar(&identityDerivePass, "passphrase", "",
+		"optional BIP39 passphrase (25th word)")
+	identityDeriveCmd.Flags().Uint32Var(&identityDeriveIndex, "index", 0,
+		"account index on the wallet derivation path")
+	identityDeriveCmd.Flags().StringVar(&identityDeriveOut, "out", "",
+		"also write the derived key to this file")
+	identityCmd.AddCommand(identityCheckCmd, identityNewCmd, identityShowCmd, identityDeriveCmd)
+	rootCmd.AddCommand(identityCmd)
+}
+
+// writeIdentityKey stores a private key the way go-spacemesh does: hex
+// text, readable only by the owner.
+func writeIdentityKey(path string, private ed25519.PrivateKey, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("%s already exists; pass --force to overwrite (the old identity is lost)", path)
+	}
+	err := os.WriteFile(path, []byte(hex.EncodeToString(private)), 0o600)
+	audit.Record("identity new", path, nil, err)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("NodeID: %x\n", private.Public().(ed25519.PublicKey))
+	fmt.Printf("key written to %s\n", path)
+	return nil
+}
+
+func deriveIdentity() error {
+	var seed []byte
+	switch {
+	case identityDeriveSeed != "" && identityDeriveMnemonic != "":
+		return fmt.Errorf("--seed and --mnemonic are mutually exclusive")
+	case identityDeriveSeed != "":
+		decoded, err := hex.DecodeString(identityDeriveSeed)
+		if err != nil || len(decoded) != ed25519.SeedSize {
+			return fmt.Errorf("--seed must be %d bytes of hex", ed25519.SeedSize)
+		}
+		seed = decoded
+	case identityDeriveMnemonic != "":
+		seed = walletSeed(identityDeriveMnemonic, identityDerivePass, identityDeriveIndex)
+	default:
+		return fmt.Errorf("pass --seed or --mnemonic")
+	}
+
+	private := ed25519.NewKeyFromSeed(seed)
+	fmt.Printf("NodeID: %x\n", private.Public().(ed25519.PublicKey))
+	if identityDeriveOut != "" {
+		return writeIdentityKey(identityDeriveOut, private, false)
+	}
+	return nil
+}
+
+// walletSeed turns a BIP39 mnemonic into the ed25519 seed at the
+// Spacemesh wallet path m/44'/540'/0'/0'/index' (SLIP-10, all segments
+// hardened as ed25519 requires).
+func walletSeed(mnemonic, passphrase string, index uint32) []byte {
+	master := pbkdf2.Key([]byte(strings.TrimSpace(mnemonic)),
+		[]byte("mnemonic"+passphrase), 2048, 64, sha512.New)
+
+	const hardened = 0x80000000
+	mac := hmac.New(sha512.New, []byte("ed25519 seed"))
+	mac.Write(master)
+	derived := mac.Sum(nil)
+	for _, segment := range []uint32{44, 540, 0, 0, index} {
+		key, chainCode := derived[:32], derived[32:]
+		mac = hmac.New(sha512.New, chainCode)
+		mac.Write([]byte{0})
+		mac.Write(key)
+		mac.Write(binary.BigEndian.AppendUint32(nil, segment|hardened))
+		derived = mac.Sum(nil)
+	}
+	return derived[:32]
+}
+
+func checkIdentity(cmd *cobra.Command, dir string) {
+	keyPath := identityCheckKey
+	if keyPath == "" {
+		keyPath = filepath.Join(dir, "key.bin")
+	}
+	public, err := publicKeyFromFile(keyPath)
+	if err != nil {
+		fail(err)
+		return
+	}
+	fmt.Printf("%s: public key %x\n", keyPath, public)
+
+	found, err := plots.Scan(dir)
+	if err != nil {
+		fail(err)
+		return
+	}
+	mismatches := 0
+	for _, plot := range found {
+		if bytes.Equal(plot.Metadata.NodeId, public) {
+			fmt.Printf("%s: NodeId matches\n", plot.Dir)
+		} else {
+			mismatches++
+			fmt.Printf("%s: NodeId %x belongs to a DIFFERENT identity\n", plot.Dir, plot.Metadata.NodeId)
+		}
+	}
+	if len(found) == 0 {
+		fmt.Println("no plots found under the node directory")
+	}
+
+	if identityCheckGRPC != "" {
+		smesherID, err := nodeSmesherID(cmd, identityCheckGRPC)
+		if err != nil {
+			fmt.Printf("node query failed: %v\n", err)
+		} else if bytes.Equal(smesherID, public) {
+			fmt.Printf("node at %s: smesher ID matches\n", identityCheckGRPC)
+		} else {
+			mismatches++
+			fmt.Printf("node at %s: smesher ID %x belongs to a DIFFERENT identity\n", identityCheckGRPC, smesherID)
+		}
+	}
+
+	if mismatches == 0 {
+		fmt.Println("identity is consistent")
+	} else {
+		fmt.Printf("%d mismatch(es); the node will reject proofs from the mismatched plots\n", mismatches)
+	}
+}
+
+// publicKeyFromFile derives the ed25519 public key from a node key
+// file. Nodes have stored the key as a raw seed, a raw private key and
+// hex text of either over the years; all four are accepted.
+func publicKeyFromFile(path string) (ed25519.PublicKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if text := strings.TrimSpace(string(raw)); len(text) == hex.EncodedLen(ed25519.SeedSize) ||
+		len(text) == hex.EncodedLen(ed25519.PrivateKeySize) {
+		if decoded, err := hex.DecodeString(text); err == nil {
+			raw = decoded
+		}
+	}
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(raw).Public().(ed25519.PublicKey), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(raw).Public().(ed25519.PublicKey), nil
+	default:
+		return nil, fmt.Errorf("%s: want an ed25519 seed or private key (raw or hex), got %d bytes", path, len(raw))
+	}
+}
+
+// nodeSmesherID asks a running node for its smesher ID.
+func nodeSmesherID(cmd *cobra.Command, target string) ([]byte, error) {
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	resp, err := pb.NewSmesherServiceClient(conn).SmesherID(cmd.Context(), &emptypb.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetPublicKey(), nil
+}
@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/leveleven/smtool/internal/audit"
+	"github.com/leveleven/smtool/internal/gpulock"
+	"github.com/leveleven/smtool/internal/metrics"
+	"github.com/leveleven/smtool/internal/postformat"
+	"github.com/leveleven/smtool/internal/provenance"
+	"github.com/leveleven/smtool/internal/resources"
+	"github.com/leveleven/smtool/internal/usage"
+	"github.com/leveleven/smtool/postrs"
+	"github.com/spacemeshos/post/shared"
+	"github.com/spf13/cobra"
+)
+
+var (
+	initDataDir     string
+	initNodeID      string
+	initCommitment  string
+	initNumUnits    uint32
+	initMaxFileSize uint64
+	initProvider    int
+	initForce       bool
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "initialize POST data (plotting) for an identity",
+	Long: `Plot POST data through the same dlopen'd libpost the other commands
+use, so plotting and nonce search live in one CLI instead of juggling
+postcli and smtool. An interrupted init resumes from the labels
+already on disk, and a label that beats the VRF difficulty during
+plotting is stored as the nonce; follow up with "smtool genonce" if
+none was found.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := initialize(cmd); err != nil {
+			fail(err)
+		}
+	},
+}
+
+func init() {
+	initCmd.Flags().StringVar(&initDataDir, "datadir", "",
+		"directory to write the plot into (required)")
+	initCmd.Flags().StringVar(&initNodeID, "node-id", "",
+		"hex 32-byte node ID (the identity's public key)")
+	initCmd.Flags().StringVar(&initCommitment, "commitment-atx-id", "",
+		"hex 32-byte commitment ATX ID")
+	initCmd.Flags().Uint32Var(&initNumUnits, "numUnits", 4,
+		"space units to plot")
+	initCmd.Flags().Uint64Var(&initMaxFileSize, "maxFileSize", 4<<30,
+		"maximum size of each postdata file in bytes")
+	initCmd.Flags().IntVar(&initProvider, "provider", postrs.CPUProviderID,
+		"compute provider ID to plot with (see smtool providers)")
+	initCmd.Flags().BoolVar(&initForce, "force", false,
+		"use the provider even if it previously failed its known-answer test")
+	initCmd.MarkFlagRequired("datadir")
+	initCmd.MarkFlagRequired("node-id")
+	initCmd.MarkFlagRequired("commitment-atx-id")
+	rootCmd.AddCommand(initCmd)
+}
+
+func initialize(cmd *cobra.Command) error {
+	nodeID, err := hex.DecodeString(initNodeID)
+	if err != nil || len(nodeID) != 32 {
+		return fmt.Errorf("--node-id must be 64 hex chars")
+	}
+	commitment, err := hex.DecodeString(initCommitment)
+	if err != nil || len(commitment) != 32 {
+		return fmt.Errorf("--commitment-atx-id must be 64 hex chars")
+	}
+	if initNumUnits == 0 {
+		return fmt.Errorf("--numUnits must be positive")
+	}
+	if err := checkProviderFingerprint(initProvider, initForce); err != nil {
+		return err
+	}
+
+	// A plot already in the directory means a resume; its geometry wins
+	// over the flags, and a different identity is a hard error because
+	// mixing labels from two commitments yields an unprovable plot.
+	metadata, err := loadMetadata(initDataDir)
+	switch {
+	case err == nil:
+		if !bytes.Equal(metadata.NodeId, nodeID) || !bytes.Equal(metadata.CommitmentAtxId, commitment) {
+			return fmt.Errorf("%s already holds a plot for a different identity; use smtool rekey to migrate it", initDataDir)
+		}
+		if metadata.NumUnits != initNumUnits {
+			return fmt.Errorf("%s is plotted for %d units; use smtool resize to change the size", initDataDir, metadata.NumUnits)
+		}
+	case errors.Is(err, ErrMetadataMissing):
+		if err := os.MkdirAll(initDataDir, 0o700); err != nil {
+			return err
+		}
+		metadata = &shared.PostMetadata{
+			NodeId:          nodeID,
+			CommitmentAtxId: commitment,
+			NumUnits:        initNumUnits,
+			LabelsPerUnit:   defaultLabelsPerUnit,
+			MaxFileSize:     initMaxFileSize,
+		}
+		if err := saveMetadata(initDataDir, metadata); err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+
+	format := postformat.ForMetadata(metadata)
+	labelsPerFile := metadata.MaxFileSize / format.LabelSize
+	if labelsPerFile == 0 {
+		return fmt.Errorf("metadata reports MaxFileSize %d, smaller than one label", metadata.MaxFileSize)
+	}
+	numLabels := uint64(metadata.NumUnits) * metadata.LabelsPerUnit
+	start := plottedLabels(initDataDir, labelsPerFile, format.LabelSize)
+	if start >= numLabels {
+		fmt.Println("plot already holds all labels")
+		return nil
+	}
+	if start > 0 {
+		fmt.Printf("resuming plotting at position %d\n", start)
+	}
+
+	releaseSlot, err := resources.AcquireGPU(cmd.Context())
+	if err != nil {
+		return err
+	}
+	defer releaseSlot()
+	// Hold the device lock like genonce does; libpost knows nothing
+	// about other smtool jobs on the same card.
+	releaseDevice, err := gpulock.Acquire(fmt.Sprint(initProvider), true)
+	if err != nil {
+		return err
+	}
+	defer releaseDevice()
+
+	// Handing the oracle the difficulty makes it flag a qualifying
+	// label while plotting, saving the separate genonce pass when one
+	// turns up in the data anyway.
+	wo, err := postrs.NewOracle(
+		postrs.WithProviderID(initProvider),
+		postrs.WithCommitment(postrs.CommitmentBytes(nodeID, commitment)),
+		postrs.WithVRFDifficulty(nonceDifficulty(numLabels)),
+	)
+	if err != nil {
+		return err
+	}
+	defer wo.Close()
+
+	startTime := time.Now()
+	written, err := plotLabels(cmd.Context(), wo, metadata, format, start, numLabels)
+	usage.Collect(startTime).Print("labels", written)
+	audit.Record("init", initDataDir, map[string]string{
+		"numUnits": fmt.Sprint(initNumUnits),
+		"provider": fmt.Sprint(initProvider),
+	}, err)
+	if err != nil {
+		return err
+	}
+	if err := saveMetadata(initDataDir, metadata); err != nil {
+		return err
+	}
+	if err := provenance.Append(initDataDir, Version, "init", fmt.Sprint(initProvider)); err != nil {
+		fmt.Printf("provenance: %v\n", err)
+	}
+
+	if metadata.Nonce != nil {
+		fmt.Printf("plotting complete, nonce found: %d\n", *metadata.Nonce)
+	} else {
+		fmt.Println("plotting complete, no nonce found yet; run smtool genonce")
+	}
+	return nil
+}
+
+// plotLabels computes labels for positions [start, numLabels) and lays
+// them out under the MaxFileSize file layout, recording the first
+// qualifying nonce in the metadata. Batches align to file boundaries so
+// each oracle result lands in exactly one file, and are capped so a
+// large plot never needs a file-sized buffer.
+func plotLabels(ctx context.Context, wo *postrs.WorkOracle, metadata *shared.PostMetadata,
+	format postformat.Format, start, numLabels uint64,
+) (uint64, error) {
+	labelsPerFile := metadata.MaxFileSize / format.LabelSize
+	var written uint64
+	for position := start; position < numLabels; {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+		if err := pauseOnBatteryPower(ctx); err != nil {
+			return written, err
+		}
+		end := position + nonceBatchSize
+		if boundary := (position/labelsPerFile + 1) * labelsPerFile; boundary < end {
+			end = boundary
+		}
+		if end > numLabels {
+			end = numLabels
+		}
+		result, err := wo.Positions(position, end-1)
+		if err != nil {
+			return written, err
+		}
+		if result.Nonce != nil && metadata.Nonce == nil {
+			metadata.Nonce = result.Nonce
+			metadata.NonceValue = format.Label(result.Output, position, *result.Nonce)
+		}
+		name := filepath.Join(initDataDir, fmt.Sprintf("postdata_%d.bin", position/labelsPerFile))
+		file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return written, err
+		}
+		_, err = file.WriteAt(result.Output, int64((position%labelsPerFile)*format.LabelSize))
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return written, err
+		}
+		written += end - position
+		metrics.LabelsScanned.Add(end - position)
+		position = end
+	}
+	return written, nil
+}
+
+// plottedLabels counts the whole labels already on disk, stopping at
+// the first missing or short file so the count is contiguous from
+// position 0 and a resumed init skips nothing.
+func plottedLabels(dir string, labelsPerFile, labelSize uint64) uint64 {
+	var labels uint64
+	for index := uint64(0); ; index++ {
+		info, err := os.Stat(filepath.Join(dir, fmt.Sprintf("postdata_%d.bin", index)))
+		if err != nil {
+			return labels
+		}
+		labels += uint64(info.Size()) / labelSize
+		if uint64(info.Size()) < labelsPerFile*labelSize {
+			return labels
+		}
+	}
+}
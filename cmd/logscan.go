@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/leveleven/smtool/internal/nodelog"
+	"github.com/spf13/cobra"
+)
+
+var logscanFile string
+
+var logscanCmd = &cobra.Command{
+	Use:   "logscan",
+	Short: "diagnose known failure signatures in a node log",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := scanLog(logscanFile); err != nil {
+			fail(err)
+		}
+	},
+}
+
+func init() {
+	logscanCmd.Flags().StringVar(&logscanFile, "file", "", "path to the node log file")
+	logscanCmd.MarkFlagRequired("file")
+	rootCmd.AddCommand(logscanCmd)
+}
+
+// logRule maps a known failure signature to an explanation and the
+// smtool command that investigates it further.
+type logRule struct {
+	name    string
+	pattern *regexp.Regexp
+	explain string
+	suggest string
+}
+
+// logRules is the curated ruleset. Order matters: the first matching
+// rule claims the line.
+var logRules = []logRule{
+	{
+		name:    "post verification failed",
+		pattern: regexp.MustCompile(`(?i)(post|proof).{0,20}(verification|validation).{0,20}fail`),
+		explain: "the node rejected a generated POST proof; the plot data or nonce may be corrupt",
+		suggest: "smtool verifyproof --metadata <post_metadata.bin> <post.bin>  (and check the plot with smtool genonce)",
+	},
+	{
+		name:    "poet registration missed",
+		pattern: regexp.MustCompile(`(?i)poet.{0,30}(registration|round).{0,30}(miss|fail|too late|closed)`),
+		explain: "the node failed to register with a poet round in time; the next ATX will be delayed by an epoch",
+		suggest: "smtool post-mortem --log <node.log>",
+	},
+	{
+		name:    "database corruption",
+		pattern: regexp.MustCompile(`(?i)(database|sqlite|state).{0,20}(corrupt|malformed|disk image)`),
+		explain: "the node database is damaged, usually after power loss or a full disk",
+		suggest: "restore from a checkpoint; inspect plots with smtool support-bundle first",
+	},
+	{
+		name:    "vrf nonce missing",
+		pattern: regexp.MustCompile(`(?i)(vrf )?nonce.{0,20}(missing|not found|required)`),
+		explain: "the plot metadata has no VRF nonce, so the node cannot smesh with it",
+		suggest: "smtool genonce --path <plot dir>",
+	},
+	{
+		name:    "post metadata unreadable",
+		pattern: regexp.MustCompile(`(?i)(load|read|parse).{0,20}post.{0,20}metadata.{0,20}(fail|error)`),
+		explain: "postdata_metadata.json is missing or damaged",
+		suggest: "smtool support-bundle --path <plot dir>",
+	},
+	{
+		name:    "proving deadline overrun",
+		pattern: regexp.MustCompile(`(?i)(proof|proving).{0,30}(deadline|too slow|timed? ?out)`),
+		explain: "proof generation did not finish inside the proving window; storage may be too slow",
+		suggest: "smtool tiers --root <plots root>",
+	},
+}
+
+// ruleHit accumulates the occurrences of one rule.
+type ruleHit struct {
+	count       int
+	first, last time.Time
+	sample      string
+}
+
+func scanLog(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hits := make(map[string]*ruleHit)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := nodelog.Parse(scanner.Text())
+		for _, rule := range logRules {
+			if !rule.pattern.MatchString(line.Text) {
+				continue
+			}
+			hit := hits[rule.name]
+			if hit == nil {
+				hit = &ruleHit{first: line.Time, sample: line.Text}
+				hits[rule.name] = hit
+			}
+			hit.count++
+			hit.last = line.Time
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if len(hits) == 0 {
+		fmt.Println("no known failure signatures found")
+		return nil
+	}
+	for _, rule := range logRules {
+		hit, ok := hits[rule.name]
+		if !ok {
+			continue
+		}
+		fmt.Printf("%s (%d occurrence(s)", rule.name, hit.count)
+		if !hit.first.IsZero() {
+			fmt.Printf(", %s – %s", hit.first.Format(time.RFC3339), hit.last.Format(time.RFC3339))
+		}
+		fmt.Println(")")
+		fmt.Printf("  what it means: %s\n", rule.explain)
+		fmt.Printf("  investigate:   %s\n", rule.suggest)
+		fmt.Printf("  example:       %.120s\n\n", hit.sample)
+	}
+	return nil
+}
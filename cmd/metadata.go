@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/leveleven/smtool/internal/audit"
+	"github.com/spacemeshos/post/shared"
+	"github.com/spf13/cobra"
+)
+
+var metadataRestorePath string
+
+var metadataCmd = &cobra.Command{
+	Use:   "metadata",
+	Short: "plot metadata file operations",
+}
+
+var metadataRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "roll postdata_metadata.json back to its .bak version",
+	Long: `Every metadata write keeps the previous version as
+postdata_metadata.json.bak. Restore swaps the two, so a bad edit (or a
+nonce search against the wrong difficulty) is a one-command rollback;
+running restore twice swaps them back.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := resolvePath(metadataRestorePath)
+		if err != nil {
+			fail(err)
+			return
+		}
+		err = restoreMetadata(dir)
+		audit.Record("metadata restore", dir, nil, err)
+		if err != nil {
+			fail(err)
+		}
+	},
+}
+
+func init() {
+	metadataRestoreCmd.Flags().StringVar(&metadataRestorePath, "path", "",
+		"plot directory containing postdata_metadata.json (default: discovered data directory)")
+	metadataCmd.AddCommand(metadataRestoreCmd)
+	rootCmd.AddCommand(metadataCmd)
+}
+
+// restoreMetadata swaps postdata_metadata.json with its .bak. The
+// backup is validated as parseable metadata first; restoring a torn
+// backup over a good file would make things worse.
+func restoreMetadata(dir string) error {
+	path := filepath.Join(dir, metadataFileName)
+	backup := path + ".bak"
+
+	data, err := os.ReadFile(backup)
+	if err != nil {
+		return fmt.Errorf("no backup to restore: %w", err)
+	}
+	if err := json.Unmarshal(data, new(shared.PostMetadata)); err != nil {
+		return fmt.Errorf("%s is not valid metadata: %w", backup, err)
+	}
+
+	swap := path + ".swap"
+	if err := os.Rename(path, swap); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Rename(backup, path); err != nil {
+		os.Rename(swap, path) // put the original back
+		return err
+	}
+	if err := os.Rename(swap, backup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	fmt.Printf("restored %s from its backup (run restore again to undo)\n", metadataFileName)
+	return nil
+}
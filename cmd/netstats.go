@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/leveleven/smtool/internal/export"
+	"github.com/leveleven/smtool/internal/netstats"
+	"github.com/spf13/cobra"
+)
+
+var (
+	netstatsSource string
+	netstatsEpochs int
+	netstatsOut    string
+)
+
+var netstatsCmd = &cobra.Command{
+	Use:   "netstats",
+	Short: "show network space and smesher counts over recent epochs",
+	Long: `Pull total committed space and active smesher counts for the last N
+epochs from an explorer API, cache them locally and plot the trend as a
+terminal sparkline — the inputs for deciding whether growing a plot is
+worth it.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		stats, err := netstats.Fetch(cmd.Context(), netstatsSource, netstatsEpochs)
+		if err != nil {
+			fail(err)
+			return
+		}
+
+		if netstatsOut != "" {
+			table := &export.Table{Columns: []export.Column{
+				{Name: "epoch", Kind: export.Int},
+				{Name: "totalSpace", Kind: export.Int},
+				{Name: "smeshers", Kind: export.Int},
+			}}
+			for _, s := range stats {
+				table.Append(int64(s.Epoch), int64(s.TotalSpace), int64(s.Smeshers))
+			}
+			if err := export.Write(netstatsOut, table); err != nil {
+				fail(err)
+				return
+			}
+			fmt.Printf("%d epochs written to %s\n", len(table.Rows), netstatsOut)
+			return
+		}
+
+		space := make([]uint64, len(stats))
+		smeshers := make([]uint64, len(stats))
+		for i, s := range stats {
+			space[i] = s.TotalSpace
+			smeshers[i] = s.Smeshers
+		}
+		first, last := stats[0], stats[len(stats)-1]
+		fmt.Printf("epochs %d..%d\n", first.Epoch, last.Epoch)
+		fmt.Printf("network space: %s  %s → %s\n",
+			netstats.Sparkline(space), formatBytes(first.TotalSpace), formatBytes(last.TotalSpace))
+		fmt.Printf("smeshers:      %s  %d → %d\n",
+			netstats.Sparkline(smeshers), first.Smeshers, last.Smeshers)
+		if last.TotalSpace > first.TotalSpace && first.TotalSpace > 0 {
+			growth := float64(last.TotalSpace-first.TotalSpace) / float64(first.TotalSpace) * 100
+			fmt.Printf("space grew %.1f%% over the window; per-unit rewards shrink accordingly\n", growth)
+		}
+	},
+}
+
+func init() {
+	netstatsCmd.Flags().StringVar(&netstatsSource, "source",
+		"https://explorer-api.spacemesh.network", "explorer API base URL")
+	netstatsCmd.Flags().IntVar(&netstatsEpochs, "epochs", 20, "number of recent epochs to show")
+	netstatsCmd.Flags().StringVar(&netstatsOut, "out", "",
+		"export the epochs to this .csv or .parquet file instead of printing")
+	rootCmd.AddCommand(netstatsCmd)
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+
+	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+var nodeGRPC string
+
+var nodeCmd = &cobra.Command{
+	Use:   "node",
+	Short: "query a running go-spacemesh node over gRPC",
+	Long: `Inspect node and smeshing state from the same toolbox used for file
+inspection. status and highest-atx use the public API (default port
+9092); post-states needs the private smesher API, usually port 9093:
+
+  smtool node status
+  smtool node post-states --grpc localhost:9093
+  smtool node highest-atx`,
+}
+
+var nodeStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "sync and peer status of the node",
+	Run: func(cmd *cobra.Command, args []string) {
+		conn, err := nodeDial()
+		if err != nil {
+			fail(err)
+			return
+		}
+		defer conn.Close()
+		resp, err := pb.NewNodeServiceClient(conn).Status(cmd.Context(), &pb.StatusRequest{})
+		if err != nil {
+			fail(err)
+			return
+		}
+		status := resp.GetStatus()
+		fmt.Printf("synced:         %v\n", status.GetIsSynced())
+		fmt.Printf("peers:          %d\n", status.GetConnectedPeers())
+		fmt.Printf("synced layer:   %d\n", status.GetSyncedLayer().GetNumber())
+		fmt.Printf("top layer:      %d\n", status.GetTopLayer().GetNumber())
+		fmt.Printf("verified layer: %d\n", status.GetVerifiedLayer().GetNumber())
+	},
+}
+
+var nodePostStatesCmd = &cobra.Command{
+	Use:   "post-states",
+	Short: "POST setup state of the node's identities",
+	Run: func(cmd *cobra.Command, args []string) {
+		conn, err := nodeDial()
+		if err != nil {
+			fail(err)
+			return
+		}
+		defer conn.Close()
+		resp, err := pb.NewSmesherServiceClient(conn).PostSetupStatus(cmd.Context(), &emptypb.Empty{})
+		if err != nil {
+			fail(err)
+			return
+		}
+		status := resp.GetStatus()
+		fmt.Printf("state:          %s\n", status.GetState())
+		fmt.Printf("labels written: %d\n", status.GetNumLabelsWritten())
+		if opts := status.GetOpts(); opts != nil {
+			fmt.Printf("data dir:       %s\n", opts.GetDataDir())
+			fmt.Printf("units:          %d\n", opts.GetNumUnits())
+			fmt.Printf("max file size:  %s\n", formatBytes(opts.GetMaxFileSize()))
+		}
+	},
+}
+
+var nodeHighestAtxCmd = &cobra.Command{
+	Use:   "highest-atx",
+	Short: "the highest ATX the node knows, the usual positioning target",
+	Run: func(cmd *cobra.Command, args []string) {
+		conn, err := nodeDial()
+		if err != nil {
+			fail(err)
+			return
+		}
+		defer conn.Close()
+		resp, err := pb.NewActivationServiceClient(conn).Highest(cmd.Context(), &emptypb.Empty{})
+		if err != nil {
+			fail(err)
+			return
+		}
+		atx := resp.GetAtx()
+		fmt.Printf("id:        %x\n", atx.GetId().GetId())
+		fmt.Printf("smesher:   %x\n", atx.GetSmesherId().GetId())
+		fmt.Printf("units:     %d\n", atx.GetNumUnits())
+		fmt.Printf("sequence:  %d\n", atx.GetSequence())
+		fmt.Printf("layer:     %d\n", atx.GetLayer().GetNumber())
+	},
+}
+
+func init() {
+	nodeCmd.PersistentFlags().StringVar(&nodeGRPC, "grpc", "localhost:9092",
+		"gRPC endpoint of the node API")
+	nodeCmd.AddCommand(nodeStatusCmd, nodePostStatesCmd, nodeHighestAtxCmd)
+	rootCmd.AddCommand(nodeCmd)
+}
+
+// nodeDial connects to the configured node endpoint.
+func nodeDial() (*grpc.ClientConn, error) {
+	return grpc.NewClient(nodeGRPC, grpc.WithTransportCredentials(insecure.NewCredentials()))
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/leveleven/smtool/internal/render"
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spf13/cobra"
+)
+
+var parseBallotCmd = &cobra.Command{
+	Use:   "parseBallot [ballot.bin]",
+	Short: "decode a scale-encoded Ballot from a file or hex on stdin",
+	Long: `Decode a Ballot and print what matters when debugging consensus
+participation: the layer, the backing ATX, the eligibility proofs, the
+votes and — for a reference ballot — the beacon and eligibility count.
+Ballots come from the node's sqlite (ballots table) or from API
+responses; without a file argument the blob is read as hex from stdin.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var data []byte
+		var err error
+		if len(args) == 1 {
+			data, err = read(args[0])
+		} else {
+			data, err = readHexStdin()
+		}
+		if err != nil {
+			fail(err)
+			return
+		}
+
+		var ballot types.Ballot
+		if err := codec.Decode(data, &ballot); err != nil {
+			fail(err)
+			return
+		}
+		if render.Format != "spew" {
+			if err := render.Dump(ballot); err != nil {
+				fail(err)
+			}
+			return
+		}
+		printBallot(&ballot)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(parseBallotCmd)
+}
+
+// printBallot prints the consensus-debugging view of a ballot. A ballot
+// either carries EpochData (it is the identity's reference ballot for
+// the epoch) or points at one via RefBallot.
+func printBallot(ballot *types.Ballot) {
+	fmt.Printf("Layer:         %d\n", ballot.Layer)
+	fmt.Printf("AtxID:         %x\n", ballot.AtxID)
+	fmt.Printf("SmesherID:     %x\n", ballot.SmesherID)
+	fmt.Printf("OpinionHash:   %x\n", ballot.OpinionHash)
+	if ballot.EpochData != nil {
+		fmt.Printf("EpochData:     reference ballot\n")
+		fmt.Printf("  Beacon:           %x\n", ballot.EpochData.Beacon)
+		fmt.Printf("  ActiveSetHash:    %x\n", ballot.EpochData.ActiveSetHash)
+		fmt.Printf("  EligibilityCount: %d\n", ballot.EpochData.EligibilityCount)
+	} else {
+		fmt.Printf("RefBallot:     %x\n", ballot.RefBallot)
+	}
+
+	// The J values show which of the epoch's eligibility slots this
+	// ballot exercises; gaps across a layer's ballots mean missed slots.
+	fmt.Printf("Eligibilities: %d\n", len(ballot.EligibilityProofs))
+	for _, proof := range ballot.EligibilityProofs {
+		fmt.Printf("  J %d, VRF %x\n", proof.J, proof.Sig)
+	}
+
+	votes := ballot.Votes
+	fmt.Printf("Votes:         base %x, %d support, %d against, %d abstain\n",
+		votes.Base, len(votes.Support), len(votes.Against), len(votes.Abstain))
+	for _, vote := range votes.Support {
+		fmt.Printf("  support %x layer %d height %d\n", vote.ID, vote.LayerID, vote.Height)
+	}
+	for _, vote := range votes.Against {
+		fmt.Printf("  against %x layer %d height %d\n", vote.ID, vote.LayerID, vote.Height)
+	}
+	for _, layer := range votes.Abstain {
+		fmt.Printf("  abstain layer %d\n", layer)
+	}
+}
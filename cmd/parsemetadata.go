@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/leveleven/smtool/internal/postformat"
+	"github.com/leveleven/smtool/internal/render"
+	"github.com/spacemeshos/post/shared"
+	"github.com/spf13/cobra"
+)
+
+var parseMetadataPath string
+
+var parseMetadataCmd = &cobra.Command{
+	Use:   "parseMetadata",
+	Short: "decode and schema-check postdata_metadata.json against the disk",
+	Long: `Load postdata_metadata.json, validate the schema (field presence and
+lengths, labelsPerUnit sanity) and cross-check the declared geometry
+against the label files actually on disk, reporting each inconsistency
+with what to do about it. This is the deeper, read-only cousin of
+doctor's metadata check.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := resolvePath(parseMetadataPath)
+		if err != nil {
+			fail(err)
+			return
+		}
+		parseMetadata(dir)
+	},
+}
+
+func init() {
+	parseMetadataCmd.Flags().StringVar(&parseMetadataPath, "path", "",
+		"plot directory containing postdata_metadata.json (default: discovered data directory)")
+	rootCmd.AddCommand(parseMetadataCmd)
+}
+
+func parseMetadata(dir string) {
+	metadata, err := loadMetadata(dir)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	fmt.Printf("NodeId:          %x\n", metadata.NodeId)
+	fmt.Printf("CommitmentAtxId: %x\n", metadata.CommitmentAtxId)
+	fmt.Printf("NumUnits:        %d\n", metadata.NumUnits)
+	fmt.Printf("LabelsPerUnit:   %d\n", metadata.LabelsPerUnit)
+	fmt.Printf("MaxFileSize:     %s\n", formatBytes(metadata.MaxFileSize))
+	if metadata.Nonce != nil {
+		fmt.Printf("Nonce:           %d\n", *metadata.Nonce)
+	}
+	if metadata.LastPosition != nil {
+		fmt.Printf("LastPosition:    %d\n", *metadata.LastPosition)
+	}
+	if render.Format != "spew" {
+		render.Dump(metadata)
+	}
+
+	problems := append(validateMetadata(metadata), validateGeometry(metadata)...)
+	problems = append(problems, crossCheckFiles(dir, metadata)...)
+	if len(problems) == 0 {
+		fmt.Println("metadata is consistent with the schema and the files on disk")
+		return
+	}
+	for _, problem := range problems {
+		fmt.Printf("problem: %v\n", problem)
+	}
+}
+
+// validateGeometry sanity-checks the declared plot geometry beyond the
+// field-level checks of validateMetadata.
+func validateGeometry(metadata *shared.PostMetadata) []error {
+	var problems []error
+	format := postformat.ForMetadata(metadata)
+	if metadata.LabelsPerUnit == 0 {
+		problems = append(problems, fmt.Errorf("LabelsPerUnit is 0; a plot cannot be empty"))
+	}
+	if metadata.NumUnits == 0 {
+		problems = append(problems, fmt.Errorf("NumUnits is 0; the node rejects zero-size commitments"))
+	}
+	switch {
+	case metadata.MaxFileSize == 0:
+		problems = append(problems, fmt.Errorf("MaxFileSize is 0; labels cannot be placed in files"))
+	case metadata.MaxFileSize%format.LabelSize != 0:
+		problems = append(problems, fmt.Errorf(
+			"MaxFileSize %d is not a multiple of the %d-byte label size; file boundaries would split labels",
+			metadata.MaxFileSize, format.LabelSize))
+	}
+	numLabels := uint64(metadata.NumUnits) * metadata.LabelsPerUnit
+	if metadata.Nonce != nil && *metadata.Nonce >= numLabels && numLabels > 0 {
+		problems = append(problems, fmt.Errorf(
+			"Nonce %d is beyond the plot's %d labels; clear it and run smtool genonce", *metadata.Nonce, numLabels))
+	}
+	if metadata.LastPosition != nil && *metadata.LastPosition >= numLabels && numLabels > 0 {
+		problems = append(problems, fmt.Errorf(
+			"LastPosition %d is beyond the plot's %d labels", *metadata.LastPosition, numLabels))
+	}
+	return problems
+}
+
+// crossCheckFiles compares the declared geometry with the postdata
+// files actually present: their count and individual sizes.
+func crossCheckFiles(dir string, metadata *shared.PostMetadata) []error {
+	var problems []error
+	format := postformat.ForMetadata(metadata)
+	if metadata.MaxFileSize == 0 || metadata.MaxFileSize%format.LabelSize != 0 {
+		return nil // geometry problems already reported; sizes would mislead
+	}
+	labelsPerFile := metadata.MaxFileSize / format.LabelSize
+	numLabels := uint64(metadata.NumUnits) * metadata.LabelsPerUnit
+
+	// An unfinished init only has labels up to LastPosition; size the
+	// expectation accordingly so in-progress plots are not flagged.
+	expectedLabels := numLabels
+	partial := false
+	if metadata.LastPosition != nil && *metadata.LastPosition+1 < numLabels {
+		expectedLabels = *metadata.LastPosition + 1
+		partial = true
+	}
+	if expectedLabels == 0 {
+		return nil
+	}
+
+	lastFile := (expectedLabels - 1) / labelsPerFile
+	for index := uint64(0); index <= lastFile; index++ {
+		name := fmt.Sprintf("postdata_%d.bin", index)
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			problems = append(problems, fmt.Errorf(
+				"%s is missing; labels %d-%d have no file (re-run init or restore from backup)",
+				name, index*labelsPerFile, (index+1)*labelsPerFile-1))
+			continue
+		}
+		want := int64(metadata.MaxFileSize)
+		if index == lastFile {
+			remainder := expectedLabels - index*labelsPerFile
+			want = int64(remainder * format.LabelSize)
+		}
+		switch {
+		case info.Size() < want && !(partial && index == lastFile):
+			problems = append(problems, fmt.Errorf(
+				"%s is %s, expected %s; the tail labels were never written or were truncated",
+				name, formatBytes(uint64(info.Size())), formatBytes(uint64(want))))
+		case info.Size() > int64(metadata.MaxFileSize):
+			problems = append(problems, fmt.Errorf(
+				"%s is %s, larger than MaxFileSize %s; the file layout no longer matches the metadata",
+				name, formatBytes(uint64(info.Size())), formatBytes(metadata.MaxFileSize)))
+		}
+	}
+
+	extras, _ := filepath.Glob(filepath.Join(dir, "postdata_*.bin"))
+	for _, path := range extras {
+		var index uint64
+		if _, err := fmt.Sscanf(filepath.Base(path), "postdata_%d.bin", &index); err != nil {
+			continue
+		}
+		if index > (numLabels-1)/labelsPerFile {
+			problems = append(problems, fmt.Errorf(
+				"%s is beyond the declared %d labels; left over from a larger plot (smtool resize cleans this up)",
+				filepath.Base(path), numLabels))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/leveleven/smtool/internal/render"
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spf13/cobra"
+)
+
+var parseNipostCmd = &cobra.Command{
+	Use:   "parseNipost [nipost.bin]",
+	Short: "decode nipost_challenge.bin or nipost_full.bin",
+	Long: `Decode the NIPost state files go-spacemesh keeps while building an
+ATX: nipost_challenge.bin (the challenge being worked on) and
+nipost_full.bin (the finished NIPost with the poet membership and post
+proof). These are the first things to inspect when an ATX publish
+fails. The file name picks the type; otherwise both decodings are
+tried.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var data []byte
+		var err error
+		name := ""
+		if len(args) == 1 {
+			name = args[0]
+			data, err = read(name)
+		} else {
+			data, err = readHexStdin()
+		}
+		if err != nil {
+			fail(err)
+			return
+		}
+		parseNipost(name, data)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(parseNipostCmd)
+}
+
+func parseNipost(name string, data []byte) {
+	// The challenge file is the likelier decode for ambiguous input: it
+	// is smaller and its fields are mostly fixed-size, so a full NIPost
+	// rarely mis-decodes as one but the reverse happens.
+	if strings.Contains(name, "challenge") {
+		if !tryNipostChallenge(data) {
+			fmt.Println("not a NIPostChallenge; trying the full NIPost")
+			tryNipost(data)
+		}
+		return
+	}
+	if !tryNipost(data) && !tryNipostChallenge(data) {
+		fmt.Println("not a NIPost or NIPostChallenge")
+	}
+}
+
+func tryNipostChallenge(data []byte) bool {
+	var challenge types.NIPostChallenge
+	if err := codec.Decode(data, &challenge); err != nil {
+		return false
+	}
+	fmt.Printf("NIPostChallenge\n")
+	fmt.Printf("PublishEpoch:   %d\n", challenge.PublishEpoch)
+	fmt.Printf("Sequence:       %d\n", challenge.Sequence)
+	fmt.Printf("PrevATXID:      %x\n", challenge.PrevATXID)
+	fmt.Printf("PositioningATX: %x\n", challenge.PositioningATX)
+	if challenge.CommitmentATX != nil {
+		fmt.Printf("CommitmentATX:  %x\n", *challenge.CommitmentATX)
+	}
+	if challenge.InitialPost != nil {
+		fmt.Printf("InitialPost:    nonce %d, pow %d, %d index bytes\n",
+			challenge.InitialPost.Nonce, challenge.InitialPost.Pow, len(challenge.InitialPost.Indices))
+	}
+	if render.Format != "spew" {
+		render.Dump(challenge)
+	}
+	return true
+}
+
+func tryNipost(data []byte) bool {
+	var nipost types.NIPost
+	if err := codec.Decode(data, &nipost); err != nil {
+		return false
+	}
+	fmt.Printf("NIPost\n")
+	if nipost.Post != nil {
+		fmt.Printf("Post:           nonce %d, pow %d, %d index bytes\n",
+			nipost.Post.Nonce, nipost.Post.Pow, len(nipost.Post.Indices))
+	} else {
+		fmt.Println("Post:           missing")
+	}
+	if nipost.PostMetadata != nil {
+		fmt.Printf("Challenge:      %x\n", nipost.PostMetadata.Challenge)
+		fmt.Printf("LabelsPerUnit:  %d\n", nipost.PostMetadata.LabelsPerUnit)
+	} else {
+		fmt.Println("PostMetadata:   missing")
+	}
+	fmt.Printf("Membership:     leaf index %d, %d proof nodes\n",
+		nipost.Membership.LeafIndex, len(nipost.Membership.Nodes))
+	if render.Format != "spew" {
+		render.Dump(nipost)
+	}
+	return true
+}
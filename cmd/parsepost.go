@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"github.com/leveleven/smtool/internal/render"
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spf13/cobra"
+)
+
+var parsePostCmd = &cobra.Command{
+	Use:   "parsePost <post.bin>",
+	Short: "decode a scale-encoded post.bin file",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := read(args[0])
+		if err != nil {
+			fail(err)
+			return
+		}
+		var post types.Post
+		if err := codec.Decode(data, &post); err != nil {
+			fail(err)
+			return
+		}
+		if err := render.Dump(post); err != nil {
+			fail(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(parsePostCmd)
+}
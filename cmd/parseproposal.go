@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/leveleven/smtool/internal/render"
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spf13/cobra"
+)
+
+var parseProposalCmd = &cobra.Command{
+	Use:   "parseProposal [proposal.bin]",
+	Short: "decode a scale-encoded Proposal from a file or hex on stdin",
+	Long: `Decode a Proposal — a ballot plus the transactions it proposes and
+the mesh hash it was built on — and print the ballot view followed by
+the proposal-only fields. Proposals come from the node's sqlite
+(proposals table) or from API responses; without a file argument the
+blob is read as hex from stdin.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var data []byte
+		var err error
+		if len(args) == 1 {
+			data, err = read(args[0])
+		} else {
+			data, err = readHexStdin()
+		}
+		if err != nil {
+			fail(err)
+			return
+		}
+
+		var proposal types.Proposal
+		if err := codec.Decode(data, &proposal); err != nil {
+			fail(err)
+			return
+		}
+		if render.Format != "spew" {
+			if err := render.Dump(proposal); err != nil {
+				fail(err)
+			}
+			return
+		}
+		printBallot(&proposal.Ballot)
+		fmt.Printf("MeshHash:      %x\n", proposal.MeshHash)
+		fmt.Printf("Transactions:  %d\n", len(proposal.TxIDs))
+		for _, id := range proposal.TxIDs {
+			fmt.Printf("  %x\n", id)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(parseProposalCmd)
+}
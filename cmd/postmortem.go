@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/leveleven/smtool/internal/nodelog"
+	"github.com/spf13/cobra"
+)
+
+var postMortemLog string
+
+var postMortemCmd = &cobra.Command{
+	Use:   "post-mortem",
+	Short: "reconstruct where proving time went from node logs",
+	Long: `Ingest a go-spacemesh log and rebuild a timeline for each proving
+window: data reads, k2pow solving and poet submission, plus an epoch-
+over-epoch comparison showing whether the margin is shrinking.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := analyzeProving(postMortemLog); err != nil {
+			fail(err)
+		}
+	},
+}
+
+func init() {
+	postMortemCmd.Flags().StringVar(&postMortemLog, "log", "", "path to the node log file")
+	postMortemCmd.MarkFlagRequired("log")
+	rootCmd.AddCommand(postMortemCmd)
+}
+
+// provingPhases maps timeline phases to the log signatures that mark
+// their beginning.
+var provingPhases = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"read", regexp.MustCompile(`(?i)(proof generation started|started post execution|reading post data)`)},
+	{"k2pow", regexp.MustCompile(`(?i)(k2pow|proof of work)`)},
+	{"submit", regexp.MustCompile(`(?i)(submit.*poet|poet.*registration|publishing atx)`)},
+}
+
+var (
+	provingDone  = regexp.MustCompile(`(?i)(proof generated|proof generation finished|atx published)`)
+	epochPattern = regexp.MustCompile(`(?i)epoch[":= ]+(\d+)`)
+)
+
+// provingSession is one reconstructed proving window.
+type provingSession struct {
+	epoch  string
+	start  time.Time
+	phases []struct {
+		name string
+		at   time.Time
+	}
+	end time.Time
+}
+
+func analyzeProving(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var sessions []*provingSession
+	var current *provingSession
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := nodelog.Parse(scanner.Text())
+		if line.Time.IsZero() {
+			continue
+		}
+		for _, phase := range provingPhases {
+			if !phase.pattern.MatchString(line.Text) {
+				continue
+			}
+			if current == nil || phase.name == "read" && current.end.After(current.start) {
+				current = &provingSession{start: line.Time}
+				if m := epochPattern.FindStringSubmatch(line.Text); m != nil {
+					current.epoch = m[1]
+				}
+				sessions = append(sessions, current)
+			}
+			current.phases = append(current.phases, struct {
+				name string
+				at   time.Time
+			}{phase.name, line.Time})
+			break
+		}
+		if current != nil && provingDone.MatchString(line.Text) {
+			current.end = line.Time
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		return fmt.Errorf("no proving activity recognized in %s", path)
+	}
+
+	var previousTotal time.Duration
+	for i, session := range sessions {
+		label := session.epoch
+		if label == "" {
+			label = fmt.Sprintf("#%d", i+1)
+		}
+		fmt.Printf("proving window %s (started %s)\n", label, session.start.Format(time.RFC3339))
+		for j, phase := range session.phases {
+			until := session.end
+			if j+1 < len(session.phases) {
+				until = session.phases[j+1].at
+			}
+			if until.IsZero() {
+				fmt.Printf("  %-7s %s → (no end marker)\n", phase.name, phase.at.Format("15:04:05"))
+				continue
+			}
+			fmt.Printf("  %-7s %s → %s (%v)\n", phase.name,
+				phase.at.Format("15:04:05"), until.Format("15:04:05"), until.Sub(phase.at).Round(time.Second))
+		}
+		if !session.end.IsZero() {
+			total := session.end.Sub(session.start)
+			trend := ""
+			if previousTotal > 0 && total > previousTotal {
+				trend = fmt.Sprintf("  (%v slower than previous window — margin is shrinking)", (total - previousTotal).Round(time.Second))
+			}
+			fmt.Printf("  total   %v%s\n", total.Round(time.Second), trend)
+			previousTotal = total
+		}
+		fmt.Println()
+	}
+	return nil
+}
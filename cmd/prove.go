@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/leveleven/smtool/internal/audit"
+	"github.com/leveleven/smtool/internal/usage"
+	"github.com/leveleven/smtool/postrs"
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	provePath      string
+	proveChallenge string
+	proveK1        uint32
+	proveK2        uint32
+	provePowBits   uint
+	proveNonces    uint
+	proveThreads   uint
+	proveRandomX   string
+	proveLibrary   string
+)
+
+var proveCmd = &cobra.Command{
+	Use:   "prove",
+	Short: "generate a POST proof for a plot without a node",
+	Long: `Run libpost's prover over the plot for a 32-byte challenge and write
+the result as post.bin and post_metadata.bin, making end-to-end testing
+of a plot possible without a node: prove, then smtool verifyPost. If no
+challenge is given and challenge.bin is missing, a random one is
+generated and saved so the round trip still works.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := resolvePath(provePath)
+		if err != nil {
+			fail(err)
+			return
+		}
+		if err := prove(dir); err != nil {
+			fail(err)
+		}
+	},
+}
+
+func init() {
+	proveCmd.Flags().StringVar(&provePath, "path", "",
+		"plot directory containing postdata_metadata.json (default: discovered data directory)")
+	proveCmd.Flags().StringVar(&proveChallenge, "challenge", "",
+		"hex challenge, 32 bytes (default: challenge.bin, or a fresh random one)")
+	proveCmd.Flags().Uint32Var(&proveK1, "k1", 26, "K1 parameter")
+	proveCmd.Flags().Uint32Var(&proveK2, "k2", 37, "K2 parameter")
+	proveCmd.Flags().UintVar(&provePowBits, "pow-bits", 26,
+		"k2pow difficulty as a leading-zero bit count")
+	proveCmd.Flags().UintVar(&proveNonces, "nonces", 0,
+		"nonces per labels pass (0 = libpost default)")
+	proveCmd.Flags().UintVar(&proveThreads, "threads", 0,
+		"prover threads (0 = libpost default)")
+	proveCmd.Flags().StringVar(&proveRandomX, "randomx", "light",
+		"RandomX mode for k2pow: light (~256 MiB) or fast (~2 GiB, faster)")
+	proveCmd.Flags().StringVar(&proveLibrary, "libpost", "", "libpost build to prove with")
+	rootCmd.AddCommand(proveCmd)
+}
+
+func prove(dir string) error {
+	metadata, err := loadMetadata(dir)
+	if err != nil {
+		return err
+	}
+	if problems := validateMetadata(metadata); len(problems) > 0 {
+		return fmt.Errorf("refusing to prove over invalid metadata: %v", problems[0])
+	}
+
+	powFlags := postrs.RandomXLight
+	switch proveRandomX {
+	case "light":
+	case "fast":
+		powFlags = postrs.RandomXFast
+	default:
+		return fmt.Errorf("invalid --randomx %q, want light or fast", proveRandomX)
+	}
+
+	challenge, err := proveChallengeBytes(dir)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("challenge: %x\n", challenge)
+
+	params := postrs.ProofParams{
+		K1:            proveK1,
+		K2:            proveK2,
+		PowDifficulty: powDifficultyBits(provePowBits),
+		Nonces:        proveNonces,
+		Threads:       proveThreads,
+		PowFlags:      powFlags,
+	}
+	startTime := time.Now()
+	proof, err := postrs.GenerateProof(proveLibrary, dir, challenge, params)
+	audit.Record("prove", dir, map[string]string{
+		"k1": fmt.Sprint(proveK1), "k2": fmt.Sprint(proveK2),
+		"powBits": fmt.Sprint(provePowBits),
+	}, err)
+	if err != nil {
+		return err
+	}
+	usage.Collect(startTime).Print("labels", uint64(metadata.NumUnits)*metadata.LabelsPerUnit)
+
+	post := &types.Post{
+		Nonce:   proof.Nonce,
+		Indices: proof.Indices,
+		Pow:     proof.Pow,
+	}
+	encoded, err := codec.Encode(post)
+	if err != nil {
+		return err
+	}
+	if err := write(filepath.Join(dir, "post.bin"), encoded); err != nil {
+		return err
+	}
+	proofMetadata := &types.PostMetadata{
+		Challenge:     challenge,
+		LabelsPerUnit: metadata.LabelsPerUnit,
+	}
+	encodedMetadata, err := codec.Encode(proofMetadata)
+	if err != nil {
+		return err
+	}
+	if err := write(filepath.Join(dir, "post_metadata.bin"), encodedMetadata); err != nil {
+		return err
+	}
+
+	fmt.Printf("proof: nonce %d, pow %d, %s of indices\n",
+		proof.Nonce, proof.Pow, formatBytes(uint64(len(proof.Indices))))
+	fmt.Println("wrote post.bin and post_metadata.bin; check with smtool verifyPost")
+	return nil
+}
+
+// proveChallengeBytes resolves the proving challenge: the --challenge
+// flag, then challenge.bin, then a fresh random challenge persisted for
+// later verification.
+func proveChallengeBytes(dir string) ([]byte, error) {
+	if proveChallenge != "" {
+		challenge, err := hex.DecodeString(proveChallenge)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --challenge: %w", err)
+		}
+		if len(challenge) != 32 {
+			return nil, fmt.Errorf("challenge must be 32 bytes, got %d", len(challenge))
+		}
+		return challenge, nil
+	}
+	if challenge, err := read(filepath.Join(dir, "challenge.bin")); err == nil {
+		return challenge, nil
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	challenge := make([]byte, 32)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, err
+	}
+	fmt.Println("no challenge.bin; generated a random challenge and saved it")
+	return challenge, write(filepath.Join(dir, "challenge.bin"), challenge)
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/leveleven/smtool/internal/provenance"
+	"github.com/leveleven/smtool/internal/when"
+	"github.com/spf13/cobra"
+)
+
+var provenancePath string
+
+var provenanceCmd = &cobra.Command{
+	Use:   "provenance",
+	Short: "display and verify a plot's provenance chain",
+	Long: `Show the sidecar records smtool appends whenever it initializes or
+modifies plot data — which tool version, host and provider did what,
+when — and verify the hash chain linking them, so an edited or
+truncated history is detected.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := resolvePath(provenancePath)
+		if err != nil {
+			fail(err)
+			return
+		}
+		showProvenance(dir)
+	},
+}
+
+func init() {
+	provenanceCmd.Flags().StringVar(&provenancePath, "path", "",
+		"plot directory (default: discovered data directory)")
+	rootCmd.AddCommand(provenanceCmd)
+}
+
+func showProvenance(dir string) {
+	records, err := provenance.Read(dir)
+	if err != nil {
+		fail(err)
+		return
+	}
+	if len(records) == 0 {
+		fmt.Println("no provenance records; the plot predates provenance tracking or was made by another tool")
+		return
+	}
+	for i, record := range records {
+		fmt.Printf("%2d  %s  %-10s %s %s (provider %s, host %s/%s)\n",
+			i, when.Format(record.Time), record.Op, record.Tool, record.Version,
+			record.Provider, record.Host, record.HostID)
+	}
+	if err := provenance.Verify(records); err != nil {
+		fmt.Printf("chain INVALID: %v\n", err)
+		return
+	}
+	fmt.Printf("chain valid: %d record(s)\n", len(records))
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/leveleven/smtool/internal/cpufeat"
+	"github.com/leveleven/smtool/postrs"
+	"github.com/spf13/cobra"
+)
+
+var providersLibrary string
+
+var providersCmd = &cobra.Command{
+	Use:   "providers",
+	Short: "list the compute providers libpost can initialize with",
+	Long: `Enumerate libpost's compute providers (the CPU implementation plus
+any OpenCL devices) so a GPU's provider ID can be passed to genonce
+via --provider. CPU feature warnings are included because they explain
+order-of-magnitude differences in CPU provider throughput.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		providers, err := postrs.Providers(providersLibrary)
+		if err != nil {
+			fail(err)
+			return
+		}
+		fmt.Printf("%-6s %-5s %s\n", "ID", "TYPE", "MODEL")
+		fmt.Printf("%-6d %-5s %s\n", postrs.CPUProviderID, "CPU", "libpost scrypt implementation")
+		for _, provider := range providers {
+			fmt.Printf("%-6d %-5s %s\n", provider.ID, provider.Class, provider.Model)
+		}
+
+		cpu := cpufeat.Detect()
+		if cpu.Known {
+			fmt.Printf("\ncpu: %s (%s)\n", cpu.Model, cpu)
+		}
+		for _, warning := range cpu.Warnings() {
+			fmt.Printf("warning: %s\n", warning)
+		}
+	},
+}
+
+func init() {
+	providersCmd.Flags().StringVar(&providersLibrary, "libpost", "",
+		"libpost build to enumerate providers from")
+	rootCmd.AddCommand(providersCmd)
+}
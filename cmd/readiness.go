@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/leveleven/smtool/internal/plots"
+	"github.com/leveleven/smtool/postrs"
+	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+var (
+	readinessRoots []string
+	readinessGRPC  string
+	readinessPoet  []string
+	readinessFull  bool
+)
+
+var readinessCmd = &cobra.Command{
+	Use:   "readiness",
+	Short: "score each identity's chance of earning next epoch, 0-100",
+	Long: `Combine the plot health checks, nonce status, libpost availability,
+node sync state and poet reachability into a single 0-100 readiness
+score per identity, with the top three limiting factors — a one-number
+answer to "am I actually going to earn next epoch?". --full adds the
+zero-region scan of the label files, which reads every file.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runReadiness(cmd)
+	},
+}
+
+func init() {
+	readinessCmd.Flags().StringSliceVar(&readinessRoots, "root", nil,
+		"directories to scan for plots; repeatable (default: discovered data directory)")
+	readinessCmd.Flags().StringVar(&readinessGRPC, "grpc", "localhost:9092",
+		"gRPC endpoint of the node public API")
+	readinessCmd.Flags().StringSliceVar(&readinessPoet, "poet", nil,
+		"poet server base URLs to probe; repeatable")
+	readinessCmd.Flags().BoolVar(&readinessFull, "full", false,
+		"also scan the label files for zero regions (reads every file)")
+	rootCmd.AddCommand(readinessCmd)
+}
+
+// readinessFactor is one deduction from the perfect score.
+type readinessFactor struct {
+	penalty int
+	detail  string
+}
+
+func runReadiness(cmd *cobra.Command) {
+	roots := readinessRoots
+	if len(roots) == 0 {
+		dir, err := resolvePath("")
+		if err != nil {
+			fail(err)
+			return
+		}
+		roots = []string{dir}
+	}
+	var found []plots.Plot
+	for _, root := range roots {
+		scanned, err := plots.Scan(root)
+		if err != nil {
+			fmt.Printf("%s: %v\n", root, err)
+			continue
+		}
+		found = append(found, scanned...)
+	}
+	if len(found) == 0 {
+		fmt.Println("no plots found; score 0 (initialize a plot first)")
+		return
+	}
+
+	// Host-wide factors apply to every identity equally.
+	shared := hostFactors(cmd)
+
+	for _, plot := range found {
+		factors := append(plotFactors(plot), shared...)
+		score := 100
+		for _, factor := range factors {
+			score -= factor.penalty
+		}
+		if score < 0 {
+			score = 0
+		}
+		fmt.Printf("%x (%s): %d/100\n", plot.Metadata.NodeId[:8], plot.Dir, score)
+		sort.Slice(factors, func(i, j int) bool { return factors[i].penalty > factors[j].penalty })
+		if len(factors) > 3 {
+			factors = factors[:3]
+		}
+		for _, factor := range factors {
+			fmt.Printf("  -%d %s\n", factor.penalty, factor.detail)
+		}
+		if len(factors) == 0 {
+			fmt.Println("  nothing limiting; keep the node synced and online")
+		}
+	}
+}
+
+// plotFactors scores what is wrong with one plot.
+func plotFactors(plot plots.Plot) []readinessFactor {
+	var factors []readinessFactor
+	for _, problem := range validateMetadata(plot.Metadata) {
+		factors = append(factors, readinessFactor{40, fmt.Sprintf("metadata: %v", problem)})
+	}
+	if plot.Metadata.Nonce == nil {
+		factors = append(factors, readinessFactor{25, "no VRF nonce yet; run smtool genonce"})
+	}
+	numLabels := uint64(plot.Metadata.NumUnits) * plot.Metadata.LabelsPerUnit
+	if plot.Metadata.LastPosition != nil && *plot.Metadata.LastPosition+1 < numLabels {
+		factors = append(factors, readinessFactor{35, fmt.Sprintf(
+			"initialization incomplete: %d of %d labels", *plot.Metadata.LastPosition+1, numLabels)})
+	}
+	if readinessFull {
+		if regions, err := scanZeroRegions(plot.Dir, plot.Metadata); err == nil && len(regions) > 0 {
+			factors = append(factors, readinessFactor{30, fmt.Sprintf(
+				"%d zero region(s) in the label files; see smtool doctor", len(regions))})
+		}
+	}
+	return factors
+}
+
+// hostFactors scores the environment shared by all identities: libpost,
+// node sync and poet reachability.
+func hostFactors(cmd *cobra.Command) []readinessFactor {
+	var factors []readinessFactor
+	if _, err := postrs.Available(""); err != nil {
+		factors = append(factors, readinessFactor{20, fmt.Sprintf("libpost unavailable, proving impossible: %v", err)})
+	} else {
+		for feature, missing := range missingLibpostFeatures("") {
+			if feature == postrs.FeatureProving {
+				factors = append(factors, readinessFactor{20, fmt.Sprintf(
+					"libpost lacks %s; needs >= %s", feature, missing.Since)})
+			}
+		}
+	}
+
+	conn, err := grpc.NewClient(readinessGRPC, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err == nil {
+		ctx, cancel := context.WithTimeout(cmd.Context(), 10*time.Second)
+		resp, statusErr := pb.NewNodeServiceClient(conn).Status(ctx, &pb.StatusRequest{})
+		cancel()
+		conn.Close()
+		err = statusErr
+		if statusErr == nil && !resp.GetStatus().GetIsSynced() {
+			factors = append(factors, readinessFactor{20, fmt.Sprintf(
+				"node is not synced (layer %d of %d); proofs cannot be published",
+				resp.GetStatus().GetSyncedLayer().GetNumber(), resp.GetStatus().GetTopLayer().GetNumber())})
+		}
+	}
+	if err != nil {
+		factors = append(factors, readinessFactor{15, fmt.Sprintf(
+			"node at %s unreachable: %v", readinessGRPC, err)})
+	}
+
+	for _, base := range readinessPoet {
+		if err := probePoet(base); err != nil {
+			factors = append(factors, readinessFactor{10, fmt.Sprintf("poet %s unreachable: %v", base, err)})
+		}
+	}
+	return factors
+}
+
+// probePoet checks that a poet server answers its info endpoint.
+func probePoet(base string) error {
+	client := http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(base + "/v1/info")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return nil
+}
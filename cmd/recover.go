@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/leveleven/smtool/internal/audit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	recoverNodeDir    string
+	recoverCheckpoint string
+)
+
+var recoverCmd = &cobra.Command{
+	Use:   "recover",
+	Short: "restore a node data directory from a checkpoint",
+	Long: `Validate a checkpoint file, back up the current node state, place the
+checkpoint where go-spacemesh's recovery expects it and verify the
+result, reducing the number of ways a manual recovery can be botched.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := recoverFromCheckpoint(recoverNodeDir, recoverCheckpoint)
+		audit.Record("recover", recoverNodeDir, map[string]string{"checkpoint": recoverCheckpoint}, err)
+		if err != nil {
+			fail(err)
+		}
+	},
+}
+
+func init() {
+	recoverCmd.Flags().StringVar(&recoverNodeDir, "node-dir", "", "go-spacemesh data directory")
+	recoverCmd.Flags().StringVar(&recoverCheckpoint, "checkpoint", "", "checkpoint file to restore from")
+	recoverCmd.MarkFlagRequired("node-dir")
+	recoverCmd.MarkFlagRequired("checkpoint")
+	rootCmd.AddCommand(recoverCmd)
+}
+
+// checkpointData is the subset of the checkpoint schema needed for
+// validation.
+type checkpointData struct {
+	Version string `json:"version"`
+	Data    struct {
+		CheckpointId string            `json:"id"`
+		Atxs         []json.RawMessage `json:"atxs"`
+		Accounts     []json.RawMessage `json:"accounts"`
+	} `json:"data"`
+}
+
+func recoverFromCheckpoint(nodeDir, checkpointPath string) error {
+	// 1. Validate the checkpoint before touching anything.
+	raw, err := os.ReadFile(checkpointPath)
+	if err != nil {
+		return err
+	}
+	var checkpoint checkpointData
+	if err := json.Unmarshal(raw, &checkpoint); err != nil {
+		return fmt.Errorf("checkpoint is not valid JSON: %w", err)
+	}
+	if len(checkpoint.Data.Atxs) == 0 || len(checkpoint.Data.Accounts) == 0 {
+		return fmt.Errorf("checkpoint has no ATXs or no accounts; refusing to restore from it")
+	}
+	fmt.Printf("checkpoint %s: %d atxs, %d accounts\n",
+		checkpoint.Data.CheckpointId, len(checkpoint.Data.Atxs), len(checkpoint.Data.Accounts))
+
+	if _, err := os.Stat(nodeDir); err != nil {
+		return fmt.Errorf("node dir: %w", err)
+	}
+
+	// 2. Back up the current state database, never delete it.
+	backupDir := filepath.Join(nodeDir, fmt.Sprintf("backup-%s", time.Now().Format("20060102-150405")))
+	matches, err := filepath.Glob(filepath.Join(nodeDir, "state.sql*"))
+	if err != nil {
+		return err
+	}
+	if len(matches) > 0 {
+		if err := os.MkdirAll(backupDir, 0o755); err != nil {
+			return err
+		}
+		for _, match := range matches {
+			dest := filepath.Join(backupDir, filepath.Base(match))
+			if err := os.Rename(match, dest); err != nil {
+				return fmt.Errorf("back up %s: %w", match, err)
+			}
+			fmt.Printf("moved %s → %s\n", match, dest)
+		}
+	}
+
+	// 3. Place the checkpoint where the node's recovery looks for it.
+	recoveryDir := filepath.Join(nodeDir, "recovery")
+	if err := os.MkdirAll(recoveryDir, 0o755); err != nil {
+		return err
+	}
+	placed := filepath.Join(recoveryDir, filepath.Base(checkpointPath))
+	if err := os.WriteFile(placed, raw, 0o644); err != nil {
+		return err
+	}
+
+	// 4. Post-restore invariants: the placed copy parses and the old
+	// state is out of the way.
+	placedRaw, err := os.ReadFile(placed)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(placedRaw, new(checkpointData)); err != nil {
+		return fmt.Errorf("placed checkpoint failed re-validation: %w", err)
+	}
+	if leftover, _ := filepath.Glob(filepath.Join(nodeDir, "state.sql*")); len(leftover) > 0 {
+		return fmt.Errorf("old state files still present: %v", leftover)
+	}
+
+	fmt.Printf("checkpoint placed at %s\n", placed)
+	fmt.Println("start the node with:")
+	fmt.Printf("  --recovery-uri file://%s\n", placed)
+	if len(matches) > 0 {
+		fmt.Printf("old state preserved under %s; remove it once the node is healthy\n", backupDir)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/leveleven/smtool/internal/audit"
+	"github.com/leveleven/smtool/internal/postformat"
+	"github.com/leveleven/smtool/internal/provenance"
+	"github.com/leveleven/smtool/postrs"
+	"github.com/spacemeshos/post/shared"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rekeyPath       string
+	rekeyNodeID     string
+	rekeyCommitment string
+	rekeyForce      bool
+)
+
+var rekeyCmd = &cobra.Command{
+	Use:   "rekey",
+	Short: "rewrite a plot's NodeID or CommitmentATX, invalidating its labels",
+	Long: `Rewrite postdata_metadata.json for a different nodeId and/or
+commitmentAtxId. Labels are computed over the commitment, so the
+existing data is NOT valid for the new identity; rekey demonstrates
+this by spot-checking labels against the new commitment, prints the
+re-initialization plan, and refuses to write without --force. Use this
+instead of hand-editing the JSON, which breaks plots silently.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := resolvePath(rekeyPath)
+		if err != nil {
+			fail(err)
+			return
+		}
+		if err := rekeyPlot(dir); err != nil {
+			fail(err)
+		}
+	},
+}
+
+func init() {
+	rekeyCmd.Flags().StringVar(&rekeyPath, "path", "",
+		"plot directory containing postdata_metadata.json (default: discovered data directory)")
+	rekeyCmd.Flags().StringVar(&rekeyNodeID, "node-id", "", "new NodeID as hex")
+	rekeyCmd.Flags().StringVar(&rekeyCommitment, "commitment-atx-id", "", "new CommitmentATX ID as hex")
+	rekeyCmd.Flags().BoolVar(&rekeyForce, "force", false,
+		"write the new identity even though the existing labels become invalid")
+	rootCmd.AddCommand(rekeyCmd)
+}
+
+// rekeySamples is how many labels are spot-checked against the new
+// commitment; enough to demonstrate invalidity, cheap enough to always
+// run.
+const rekeySamples = 16
+
+func rekeyPlot(dir string) error {
+	if rekeyNodeID == "" && rekeyCommitment == "" {
+		return fmt.Errorf("pass --node-id and/or --commitment-atx-id")
+	}
+	metadata, err := loadMetadata(dir)
+	if err != nil {
+		return err
+	}
+
+	updated := *metadata
+	if rekeyNodeID != "" {
+		decoded, err := hex.DecodeString(rekeyNodeID)
+		if err != nil || len(decoded) != 32 {
+			return fmt.Errorf("--node-id must be 32 bytes of hex")
+		}
+		updated.NodeId = decoded
+	}
+	if rekeyCommitment != "" {
+		decoded, err := hex.DecodeString(rekeyCommitment)
+		if err != nil || len(decoded) != 32 {
+			return fmt.Errorf("--commitment-atx-id must be 32 bytes of hex")
+		}
+		updated.CommitmentAtxId = decoded
+	}
+	if bytes.Equal(updated.NodeId, metadata.NodeId) && bytes.Equal(updated.CommitmentAtxId, metadata.CommitmentAtxId) {
+		fmt.Println("new identity equals the current one; nothing to do")
+		return nil
+	}
+	fmt.Printf("NodeID:        %x -> %x\n", metadata.NodeId, updated.NodeId)
+	fmt.Printf("CommitmentATX: %x -> %x\n", metadata.CommitmentAtxId, updated.CommitmentAtxId)
+
+	if matches, checked, err := labelsMatching(dir, &updated); err != nil {
+		fmt.Printf("spot check against the new commitment skipped: %v\n", err)
+	} else {
+		fmt.Printf("spot check: %d of %d sampled labels match the new commitment\n", matches, checked)
+		if matches == checked && checked > 0 {
+			// Only possible when the commitment bytes came out identical.
+			fmt.Println("existing labels remain valid; the metadata rewrite is safe")
+		}
+	}
+
+	fmt.Println("re-initialization plan after the rewrite:")
+	fmt.Printf("  1. smtool init --datadir %s --node-id %x --commitment-atx-id %x --numUnits %d --force\n",
+		dir, updated.NodeId, updated.CommitmentAtxId, updated.NumUnits)
+	fmt.Printf("  2. smtool genonce --path %s\n", dir)
+	fmt.Printf("  3. smtool doctor --path %s\n", dir)
+
+	if !rekeyForce {
+		return fmt.Errorf("refusing to rewrite: every label file must be recomputed for the new identity (--force to proceed)")
+	}
+
+	maybeSnapshot(dir)
+	// The old nonce and progress belong to the old commitment.
+	updated.Nonce = nil
+	updated.NonceValue = nil
+	updated.LastPosition = nil
+	err = saveMetadata(dir, &updated)
+	audit.Record("rekey", dir, map[string]string{
+		"nodeId":     fmt.Sprintf("%x", updated.NodeId),
+		"commitment": fmt.Sprintf("%x", updated.CommitmentAtxId),
+	}, err)
+	if err != nil {
+		return err
+	}
+	if err := provenance.Append(dir, Version, "rekey", ""); err != nil {
+		fmt.Printf("provenance: %v\n", err)
+	}
+	fmt.Println("metadata rewritten; the label files are now stale until re-initialized")
+	return nil
+}
+
+// labelsMatching samples labels from disk and compares them with labels
+// recomputed under the metadata's (new) commitment.
+func labelsMatching(dir string, metadata *shared.PostMetadata) (matches, checked int, err error) {
+	format := postformat.ForMetadata(metadata)
+	numLabels := uint64(metadata.NumUnits) * metadata.LabelsPerUnit
+	if numLabels == 0 {
+		return 0, 0, fmt.Errorf("plot declares no labels")
+	}
+	labelsPerFile := metadata.MaxFileSize / format.LabelSize
+	if labelsPerFile == 0 {
+		return 0, 0, fmt.Errorf("metadata reports MaxFileSize %d, smaller than one label", metadata.MaxFileSize)
+	}
+
+	wo, err := postrs.NewOracle(
+		postrs.WithProviderID(postrs.CPUProviderID),
+		postrs.WithCommitment(postrs.CommitmentBytes(metadata.NodeId, metadata.CommitmentAtxId)),
+	)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer wo.Close()
+
+	for _, position := range samplePositions(numLabels, rekeySamples) {
+		file := filepath.Join(dir, fmt.Sprintf("postdata_%d.bin", position/labelsPerFile))
+		handle, err := os.Open(file)
+		if err != nil {
+			continue
+		}
+		disk := make([]byte, format.LabelSize)
+		_, err = handle.ReadAt(disk, int64((position%labelsPerFile)*format.LabelSize))
+		handle.Close()
+		if err != nil {
+			continue
+		}
+		result, err := wo.Positions(position, position)
+		if err != nil {
+			return matches, checked, err
+		}
+		checked++
+		if bytes.Equal(disk, result.Output[:format.LabelSize]) {
+			matches++
+		}
+	}
+	return matches, checked, nil
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"math/bits"
+	"path/filepath"
+
+	"github.com/leveleven/smtool/postrs"
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	replayPath    string
+	replayLibrary string
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "replay the node's proof validation steps against a local proof",
+	Long: `Run a locally generated proof through the same validation sequence a
+node performs on ATX submission (poet membership assembly excluded),
+stopping at the first failing step. Instead of "my ATX wasn't
+published", the answer becomes "validation step 4 rejects the proof
+because ...".`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := resolvePath(replayPath)
+		if err != nil {
+			fail(err)
+			return
+		}
+		replaySubmission(dir)
+	},
+}
+
+func init() {
+	replayCmd.Flags().StringVar(&replayPath, "path", "",
+		"plot directory containing post.bin and post_metadata.bin (default: discovered data directory)")
+	replayCmd.Flags().StringVar(&replayLibrary, "libpost", "",
+		"libpost build to verify with (default: system libpost)")
+	rootCmd.AddCommand(replayCmd)
+}
+
+// replayStep is one stage of the submission flow; run returns an error
+// describing why a node would reject the submission at this stage.
+type replayStep struct {
+	name string
+	run  func(state *replayState) error
+}
+
+// replayState carries what earlier steps loaded for later ones.
+type replayState struct {
+	dir           string
+	proof         []byte
+	proofMetadata []byte
+	post          types.Post
+}
+
+// replaySteps mirrors the node's validation order: cheap structural
+// checks first, the expensive verifier call last.
+var replaySteps = []replayStep{
+	{"load proof files", func(s *replayState) error {
+		var err error
+		if s.proof, err = read(filepath.Join(s.dir, "post.bin")); err != nil {
+			return err
+		}
+		s.proofMetadata, err = read(filepath.Join(s.dir, "post_metadata.bin"))
+		return err
+	}},
+	{"decode proof", func(s *replayState) error {
+		return codec.Decode(s.proof, &s.post)
+	}},
+	{"plot metadata consistency", func(s *replayState) error {
+		metadata, err := loadMetadata(s.dir)
+		if err != nil {
+			return err
+		}
+		for _, problem := range validateMetadata(metadata) {
+			return problem
+		}
+		if metadata.Nonce == nil {
+			return fmt.Errorf("metadata has no VRF nonce; run smtool genonce")
+		}
+		if len(metadata.NonceValue) == 0 {
+			return fmt.Errorf("metadata has no NonceValue; run smtool genonce to backfill it")
+		}
+		return nil
+	}},
+	{"index bounds", func(s *replayState) error {
+		metadata, err := loadMetadata(s.dir)
+		if err != nil {
+			return err
+		}
+		numLabels := uint64(metadata.NumUnits) * metadata.LabelsPerUnit
+		if numLabels == 0 {
+			return fmt.Errorf("plot has no labels")
+		}
+		for _, position := range unpackIndices(s.post.Indices, bits.Len64(numLabels-1)) {
+			if position >= numLabels {
+				return fmt.Errorf("index %d is beyond the plot's %d labels", position, numLabels)
+			}
+		}
+		return nil
+	}},
+	{"libpost verification", func(s *replayState) error {
+		libraryPath := replayLibrary
+		if libraryPath == "" {
+			libraryPath = postrs.DefaultLibrary
+		}
+		verifier, err := postrs.NewVerifier(libraryPath)
+		if err != nil {
+			return err
+		}
+		defer verifier.Close()
+		return verifier.Verify(s.proof, s.proofMetadata)
+	}},
+}
+
+func replaySubmission(dir string) {
+	state := &replayState{dir: dir}
+	for i, step := range replaySteps {
+		if err := step.run(state); err != nil {
+			fmt.Printf("step %d (%s): FAILED\n", i+1, step.name)
+			fmt.Printf("  %v\n", err)
+			fmt.Println("a node would have rejected the submission here")
+			return
+		}
+		fmt.Printf("step %d (%s): ok\n", i+1, step.name)
+	}
+	fmt.Println("all validation steps passed; a rejection happened outside POST validation (poet membership, timing, or fees)")
+}
@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/leveleven/smtool/internal/audit"
+	"github.com/leveleven/smtool/internal/gpulock"
+	"github.com/leveleven/smtool/internal/metrics"
+	"github.com/leveleven/smtool/internal/postformat"
+	"github.com/leveleven/smtool/internal/provenance"
+	"github.com/leveleven/smtool/internal/resources"
+	"github.com/leveleven/smtool/internal/usage"
+	"github.com/leveleven/smtool/postrs"
+	"github.com/spacemeshos/post/shared"
+	"github.com/spf13/cobra"
+)
+
+var (
+	resizePath     string
+	resizeNumUnits uint32
+	resizeProvider int
+)
+
+var resizeCmd = &cobra.Command{
+	Use:   "resize",
+	Short: "grow or shrink a plot to a new numUnits in place",
+	Long: `Change an existing plot's numUnits: growth appends freshly computed
+labels, shrink truncates the label files; metadata is updated either
+way. A nonce whose position falls outside the shrunk range is cleared
+and a fresh search started, because the node rejects out-of-range
+nonces.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := resolvePath(resizePath)
+		if err != nil {
+			fail(err)
+			return
+		}
+		resizePlot(cmd, dir)
+	},
+}
+
+func init() {
+	resizeCmd.Flags().StringVar(&resizePath, "path", "",
+		"plot directory containing postdata_metadata.json (default: discovered data directory)")
+	resizeCmd.Flags().Uint32Var(&resizeNumUnits, "numUnits", 0,
+		"target space units (required)")
+	resizeCmd.Flags().IntVar(&resizeProvider, "provider", postrs.CPUProviderID,
+		"compute provider ID for growth (see smtool providers)")
+	resizeCmd.MarkFlagRequired("numUnits")
+	rootCmd.AddCommand(resizeCmd)
+}
+
+func resizePlot(cmd *cobra.Command, dir string) {
+	metadata, err := loadMetadata(dir)
+	if err != nil {
+		fail(err)
+		return
+	}
+	if resizeNumUnits == metadata.NumUnits {
+		fmt.Printf("plot already has %d units\n", resizeNumUnits)
+		return
+	}
+	// Both grow and shrink divide by the per-file label count, so reject
+	// a geometry that would make it zero before touching any files.
+	if metadata.MaxFileSize/postformat.ForMetadata(metadata).LabelSize == 0 {
+		fail(fmt.Errorf("metadata reports MaxFileSize %d, smaller than one label", metadata.MaxFileSize))
+		return
+	}
+	maybeSnapshot(dir)
+
+	oldLabels := uint64(metadata.NumUnits) * metadata.LabelsPerUnit
+	newLabels := uint64(resizeNumUnits) * metadata.LabelsPerUnit
+	fmt.Printf("resizing %d -> %d units (%d -> %d labels)\n",
+		metadata.NumUnits, resizeNumUnits, oldLabels, newLabels)
+
+	if newLabels > oldLabels {
+		err = growPlot(cmd, dir, metadata, oldLabels, newLabels)
+	} else {
+		err = shrinkPlot(dir, metadata, newLabels)
+	}
+	audit.Record("resize", dir, map[string]string{
+		"from": fmt.Sprint(metadata.NumUnits),
+		"to":   fmt.Sprint(resizeNumUnits),
+	}, err)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	metadata.NumUnits = resizeNumUnits
+	nonceCleared := false
+	switch {
+	case metadata.Nonce == nil:
+	case *metadata.Nonce >= newLabels:
+		fmt.Printf("nonce %d is outside the new range; clearing it\n", *metadata.Nonce)
+		metadata.Nonce = nil
+		metadata.NonceValue = nil
+		nonceCleared = true
+	case !nonceBeatsDifficulty(metadata.NonceValue, newLabels):
+		// The difficulty tightens as labels are added, so a grown plot's
+		// in-range nonce can stop qualifying; keeping it would only
+		// surface as a node rejection at proving time.
+		fmt.Printf("nonce %d no longer beats the difficulty for %d labels; clearing it\n",
+			*metadata.Nonce, newLabels)
+		metadata.Nonce = nil
+		metadata.NonceValue = nil
+		nonceCleared = true
+	}
+	if metadata.LastPosition != nil && *metadata.LastPosition >= newLabels {
+		metadata.LastPosition = nil
+	}
+	if err := saveMetadata(dir, metadata); err != nil {
+		fail(err)
+		return
+	}
+	if err := provenance.Append(dir, Version, "resize", fmt.Sprint(resizeProvider)); err != nil {
+		fmt.Printf("provenance: %v\n", err)
+	}
+	fmt.Println("resize complete")
+	if nonceCleared {
+		generateNonce(cmd.Context(), dir)
+	}
+}
+
+// growPlot computes labels for positions [oldLabels, newLabels) and
+// appends them to the plot's files under the existing MaxFileSize
+// layout.
+func growPlot(cmd *cobra.Command, dir string, metadata *shared.PostMetadata, oldLabels, newLabels uint64) error {
+	if err := checkProviderFingerprint(resizeProvider, false); err != nil {
+		return err
+	}
+	releaseSlot, err := resources.AcquireGPU(cmd.Context())
+	if err != nil {
+		return err
+	}
+	defer releaseSlot()
+	releaseDevice, err := gpulock.Acquire(fmt.Sprint(resizeProvider), true)
+	if err != nil {
+		return err
+	}
+	defer releaseDevice()
+
+	wo, err := postrs.NewOracle(
+		postrs.WithProviderID(resizeProvider),
+		postrs.WithCommitment(postrs.CommitmentBytes(metadata.NodeId, metadata.CommitmentAtxId)),
+	)
+	if err != nil {
+		return err
+	}
+	defer wo.Close()
+
+	format := postformat.ForMetadata(metadata)
+	labelsPerFile := metadata.MaxFileSize / format.LabelSize
+
+	startTime := time.Now()
+	var written uint64
+	defer func() {
+		usage.Collect(startTime).Print("labels", written)
+	}()
+
+	// Batches align to file boundaries so each oracle result lands in
+	// exactly one file with one write.
+	for position := oldLabels; position < newLabels; {
+		if err := cmd.Context().Err(); err != nil {
+			return err
+		}
+		if err := pauseOnBatteryPower(cmd.Context()); err != nil {
+			return err
+		}
+		end := (position/labelsPerFile + 1) * labelsPerFile
+		if end > newLabels {
+			end = newLabels
+		}
+		result, err := wo.Positions(position, end-1)
+		if err != nil {
+			return err
+		}
+		name := filepath.Join(dir, fmt.Sprintf("postdata_%d.bin", position/labelsPerFile))
+		file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		_, err = file.WriteAt(result.Output, int64((position%labelsPerFile)*format.LabelSize))
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		written += end - position
+		metrics.LabelsScanned.Add(end - position)
+		position = end
+	}
+	return nil
+}
+
+// shrinkPlot truncates the label files to newLabels positions and
+// removes files beyond the new end entirely.
+func shrinkPlot(dir string, metadata *shared.PostMetadata, newLabels uint64) error {
+	format := postformat.ForMetadata(metadata)
+	labelsPerFile := metadata.MaxFileSize / format.LabelSize
+
+	lastFile := newLabels / labelsPerFile
+	remainder := newLabels % labelsPerFile
+	if remainder == 0 && lastFile > 0 {
+		lastFile--
+		remainder = labelsPerFile
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "postdata_*.bin"))
+	if err != nil {
+		return err
+	}
+	for _, path := range files {
+		var index uint64
+		if _, err := fmt.Sscanf(filepath.Base(path), "postdata_%d.bin", &index); err != nil {
+			continue
+		}
+		switch {
+		case index > lastFile:
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			fmt.Printf("removed %s\n", filepath.Base(path))
+		case index == lastFile:
+			if err := os.Truncate(path, int64(remainder*format.LabelSize)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/leveleven/smtool/internal/export"
+	"github.com/leveleven/smtool/internal/netstats"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rewardsNumUnits     uint32
+	rewardsNetworkSpace uint64
+	rewardsSource       string
+	rewardsUnitSize     uint64
+	rewardsLayers       uint64
+	rewardsSlots        uint64
+	rewardsSubsidy      float64
+	rewardsEpochs       int
+	rewardsOut          string
+)
+
+var rewardsCmd = &cobra.Command{
+	Use:   "rewards",
+	Short: "reward estimation",
+}
+
+var rewardsEstimateCmd = &cobra.Command{
+	Use:   "estimate",
+	Short: "estimate eligibility counts and rewards per epoch",
+	Long: `Estimate expected proposal eligibilities and rewards for a plot over
+the coming epochs. Rewards are proportional to the plot's share of the
+network's committed space, so the estimate needs the total: pass it via
+--network-space, or let the command pull recent epochs from an explorer
+API and project the observed growth forward. All outputs are expected
+values — actual eligibilities vary around them epoch to epoch.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		numUnits := rewardsNumUnits
+		if numUnits == 0 {
+			// Fall back to the local plot, the usual subject of the
+			// question "what will this earn".
+			dir, err := resolveQuietly()
+			if err != nil {
+				fail(fmt.Errorf("pass --num-units, or have a plot to read it from: %w", err))
+				return
+			}
+			metadata, err := loadMetadata(dir)
+			if err != nil {
+				fail(err)
+				return
+			}
+			numUnits = metadata.NumUnits
+			fmt.Printf("using %d units from %s\n", numUnits, dir)
+		}
+		mySpace := uint64(numUnits) * rewardsUnitSize
+
+		// Growth compounds against the operator: the same plot is a
+		// smaller share of every following epoch. The projection uses the
+		// per-epoch growth observed over the explorer window, or holds
+		// the space flat when it was passed explicitly.
+		networkSpace := float64(rewardsNetworkSpace)
+		growth := 0.0
+		if rewardsNetworkSpace == 0 {
+			stats, err := netstats.Fetch(cmd.Context(), rewardsSource, 12)
+			if err != nil {
+				fail(fmt.Errorf("pass --network-space when the explorer is unreachable: %w", err))
+				return
+			}
+			first, last := stats[0], stats[len(stats)-1]
+			networkSpace = float64(last.TotalSpace)
+			if len(stats) > 1 && first.TotalSpace > 0 && last.TotalSpace > first.TotalSpace {
+				growth = math.Pow(float64(last.TotalSpace)/float64(first.TotalSpace),
+					1/float64(len(stats)-1)) - 1
+			}
+			fmt.Printf("network space %s at epoch %d, growing %.1f%% per epoch\n",
+				formatBytes(last.TotalSpace), last.Epoch, growth*100)
+		}
+		if networkSpace == 0 {
+			fail(fmt.Errorf("network space is zero"))
+			return
+		}
+		if mySpace == 0 {
+			fail(fmt.Errorf("--num-units must be positive"))
+			return
+		}
+
+		table := &export.Table{Columns: []export.Column{
+			{Name: "epoch", Kind: export.Int},
+			{Name: "networkSpace", Kind: export.Int},
+			{Name: "share", Kind: export.Float},
+			{Name: "eligibilities", Kind: export.Float},
+			{Name: "rewardSMH", Kind: export.Float},
+		}}
+		fmt.Printf("%d units (%s)\n", numUnits, formatBytes(mySpace))
+		fmt.Printf("%-6s  %-10s  %-8s  %-13s  %s\n",
+			"EPOCH", "NETSPACE", "SHARE", "ELIGIBILITIES", "REWARD")
+		for epoch := 1; epoch <= rewardsEpochs; epoch++ {
+			networkSpace *= 1 + growth
+			share := float64(mySpace) / networkSpace
+			eligibilities := share * float64(rewardsLayers*rewardsSlots)
+			reward := share * rewardsSubsidy
+			table.Append(int64(epoch), int64(networkSpace), share, eligibilities, reward)
+			fmt.Printf("%-6s  %-10s  %-8s  %-13.1f  %.2f SMH\n",
+				fmt.Sprintf("+%d", epoch), formatBytes(uint64(networkSpace)),
+				fmt.Sprintf("%.4f%%", share*100), eligibilities, reward)
+		}
+		if rewardsOut != "" {
+			if err := export.Write(rewardsOut, table); err != nil {
+				fail(err)
+				return
+			}
+			fmt.Printf("%d epochs written to %s\n", len(table.Rows), rewardsOut)
+		}
+	},
+}
+
+func init() {
+	rewardsEstimateCmd.Flags().Uint32Var(&rewardsNumUnits, "num-units", 0,
+		"space units to estimate for (default: read from the discovered plot)")
+	rewardsEstimateCmd.Flags().Uint64Var(&rewardsNetworkSpace, "network-space", 0,
+		"total network space in bytes (default: fetched from the explorer)")
+	rewardsEstimateCmd.Flags().StringVar(&rewardsSource, "source",
+		"https://explorer-api.spacemesh.network", "explorer API base URL")
+	rewardsEstimateCmd.Flags().Uint64Var(&rewardsUnitSize, "unit-size", 64<<30,
+		"bytes per space unit")
+	rewardsEstimateCmd.Flags().Uint64Var(&rewardsLayers, "layers-per-epoch", 4032,
+		"layers in an epoch")
+	rewardsEstimateCmd.Flags().Uint64Var(&rewardsSlots, "slots-per-layer", 50,
+		"proposal eligibility slots per layer")
+	rewardsEstimateCmd.Flags().Float64Var(&rewardsSubsidy, "epoch-subsidy", 1_800_000,
+		"total SMH distributed per epoch; declines slowly over time, check an explorer for the current value")
+	rewardsEstimateCmd.Flags().IntVar(&rewardsEpochs, "epochs", 4,
+		"number of future epochs to project")
+	rewardsEstimateCmd.Flags().StringVar(&rewardsOut, "out", "",
+		"export the projection to this .csv or .parquet file as well")
+	rewardsCmd.AddCommand(rewardsEstimateCmd)
+	rootCmd.AddCommand(rewardsCmd)
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/leveleven/smtool/internal/audit"
+	"github.com/leveleven/smtool/internal/snapshot"
+	"github.com/spf13/cobra"
+)
+
+// snapshotBefore creates a pre-mutation filesystem snapshot when the
+// global --snapshot flag is set and the filesystem supports it.
+var snapshotBefore bool
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback <snapshot-id>",
+	Short: "restore a filesystem snapshot taken by smtool before a mutation",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := snapshot.Rollback(args[0])
+		audit.Record("rollback", args[0], nil, err)
+		if err != nil {
+			fail(err)
+			return
+		}
+		fmt.Printf("rolled back to %s\n", args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rollbackCmd)
+}
+
+// maybeSnapshot is called by mutating commands before they touch a
+// plot. The returned id is empty when no snapshot was taken.
+func maybeSnapshot(path string) string {
+	if !snapshotBefore {
+		return ""
+	}
+	if !snapshot.Supported(path) {
+		fmt.Printf("note: %s is not on a snapshot-capable filesystem, proceeding without one\n", path)
+		return ""
+	}
+	id, err := snapshot.Create(path)
+	if err != nil {
+		fmt.Printf("snapshot failed: %v, proceeding without one\n", err)
+		return ""
+	}
+	fmt.Printf("snapshot created: %s (restore with: smtool rollback %s)\n", id, id)
+	return id
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/leveleven/smtool/internal/audit"
+	"github.com/leveleven/smtool/internal/envinfo"
+	"github.com/leveleven/smtool/internal/metrics"
+	"github.com/leveleven/smtool/internal/redact"
+	"github.com/leveleven/smtool/internal/render"
+	"github.com/leveleven/smtool/internal/resources"
+	"github.com/leveleven/smtool/internal/tracing"
+	"github.com/leveleven/smtool/internal/when"
+	"github.com/spf13/cobra"
+)
+
+var (
+	redactOutput   bool
+	commandTimeout time.Duration
+	otelEndpoint   string
+	captureEnv     bool
+	metricsAddr    string
+)
+
+// Version is the smtool build version, overridden at release time via
+// -ldflags "-X github.com/leveleven/smtool/cmd.Version=...".
+var Version = "dev"
+
+var rootCmd = &cobra.Command{
+	Use:     "smtool",
+	Short:   "spacemesh tool box",
+	Version: Version,
+}
+
+// Execute runs the root command.
+func Execute() {
+	var flushRedacted func()
+	var cancelTimeout context.CancelFunc
+	rootCmd.PersistentFlags().BoolVar(&redactOutput, "redact", false,
+		"mask node IDs, coinbases and IPs in output with per-run pseudonyms")
+	rootCmd.PersistentFlags().DurationVar(&commandTimeout, "timeout", 0,
+		"abort the command after this duration (0 = no timeout)")
+	rootCmd.PersistentFlags().StringVar(&otelEndpoint, "otel-endpoint", "",
+		"OTLP/gRPC endpoint to export trace spans to (tracing disabled when empty)")
+	rootCmd.PersistentFlags().StringVar(&debugAddr, "debug-addr", "",
+		"serve net/http/pprof and expvar on this address (disabled when empty)")
+	rootCmd.PersistentFlags().StringVar(&metricsAddr, "metrics-addr", "",
+		"serve Prometheus metrics for the running job on this address (disabled when empty)")
+	rootCmd.PersistentFlags().BoolVar(&skipChecksum, "skip-checksum", false,
+		"load .bin files even when their CRC64 trailer does not match (recovery aid)")
+	rootCmd.PersistentFlags().BoolVar(&autoPath, "auto", false,
+		"pick the first discovered data directory when --path is omitted")
+	rootCmd.PersistentFlags().Float64Var(&maxWriteMBps, "max-write-mbps", 0,
+		"pace bulk writes to this many MB/s so a node on the same disk keeps proving (0 = unlimited)")
+	rootCmd.PersistentFlags().BoolVar(&snapshotBefore, "snapshot", false,
+		"take a ZFS/btrfs snapshot before mutating a plot (restore with smtool rollback)")
+	rootCmd.PersistentFlags().BoolVar(&audit.UseSyslog, "audit-syslog", false,
+		"mirror audit records of mutating operations to syslog")
+	rootCmd.PersistentFlags().StringVar(&when.Zone, "tz", "",
+		"IANA timezone for report timestamps (default: system local zone)")
+	rootCmd.PersistentFlags().BoolVar(&pauseOnBattery, "pause-on-battery", false,
+		"pause long jobs at their next checkpoint while the host runs on battery/UPS power")
+	rootCmd.PersistentFlags().BoolVar(&memoryPlot, "memory-plot", false,
+		"operate on a small synthetic plot generated in RAM instead of a real data directory")
+	rootCmd.PersistentFlags().StringVar(&render.Format, "output", "spew",
+		"format for decoded structures: json, yaml or spew (bytes hex-encoded in json/yaml)")
+	rootCmd.PersistentFlags().IntVar(&render.MaxDepth, "max-depth", render.MaxDepth,
+		"nesting depth limit when printing decoded structures")
+	rootCmd.PersistentFlags().IntVar(&render.MaxBytes, "max-bytes", render.MaxBytes,
+		"bytes of each byte field to print before truncating (0 = unlimited)")
+	rootCmd.PersistentFlags().BoolVar(&render.Base64, "base64", false,
+		"print byte fields as base64 instead of hex")
+	rootCmd.PersistentFlags().IntVar(&resources.MaxGPUJobs, "max-gpu-jobs", 0,
+		"cap concurrent GPU jobs in this process (0 = unlimited)")
+	rootCmd.PersistentFlags().IntVar(&resources.MaxDiskWorkers, "max-disk-workers", 0,
+		"cap concurrent disk-bound workers in this process (0 = unlimited)")
+	rootCmd.PersistentFlags().Uint64Var(&resources.MaxMemory, "max-memory", 0,
+		"cap bytes of bulk file buffers held at once (0 = unlimited)")
+	rootCmd.PersistentFlags().BoolVar(&captureEnv, "capture-env", false,
+		"append a machine profile block (OS, kernel, libpost, CPU/GPU, mounts) after the command output")
+	var shutdownTracing func(context.Context) error
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		// CLI > environment > ~/.smtool.yaml; flags set on the command
+		// line are never overridden.
+		if err := applyConfigLayer(cmd.Flags(), cmd.InheritedFlags()); err != nil {
+			return err
+		}
+		if debugAddr != "" {
+			startDebugServer(debugAddr)
+		}
+		if metricsAddr != "" {
+			metrics.Serve(metricsAddr)
+		}
+		if otelEndpoint != "" {
+			var err error
+			shutdownTracing, err = tracing.Setup(cmd.Context(), otelEndpoint)
+			if err != nil {
+				return err
+			}
+		}
+		if commandTimeout > 0 {
+			ctx, cancel := context.WithTimeout(cmd.Context(), commandTimeout)
+			cancelTimeout = cancel
+			cmd.SetContext(ctx)
+		}
+		if !redactOutput {
+			return nil
+		}
+		var err error
+		flushRedacted, err = redact.Stdout(redact.New())
+		return err
+	}
+
+	err := rootCmd.ExecuteContext(context.Background())
+	if captureEnv {
+		// Appended after the command output (and any error it printed)
+		// so a failing run can be pasted into a support thread whole.
+		dir, _ := resolveQuietly()
+		fmt.Print(envinfo.Profile(dir))
+	}
+	if shutdownTracing != nil {
+		shutdownTracing(context.Background())
+	}
+	if cancelTimeout != nil {
+		cancelTimeout()
+	}
+	if flushRedacted != nil {
+		flushRedacted()
+	}
+	if err != nil {
+		fail(err)
+	}
+	// Commands record failures through fail; exit with the classified
+	// status so scripts can distinguish failure kinds.
+	if exitStatus != exitOK {
+		os.Exit(exitStatus)
+	}
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/leveleven/smtool/postrs"
+	"github.com/spf13/cobra"
+)
+
+// rpcRequest and rpcResponse implement the JSON-RPC 2.0 wire format,
+// one message per line on stdin/stdout.
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// rpcSession holds state that outlives a single call, most importantly
+// an open oracle so scripted callers pay provider initialization once
+// instead of once per request.
+type rpcSession struct {
+	oracle *postrs.WorkOracle
+}
+
+var rpcStdioCmd = &cobra.Command{
+	Use:   "rpc-stdio",
+	Short: "serve smtool functions over JSON-RPC on stdin/stdout",
+	Long: `Read JSON-RPC 2.0 requests line by line from stdin and write one
+response per line to stdout, so Python or Node automation can drive a
+long-lived smtool session without process-per-call overhead. The oracle
+opened with oracle.open stays loaded across oracle.positions calls.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		session := &rpcSession{}
+		defer session.close()
+
+		scanner := bufio.NewScanner(os.Stdin)
+		scanner.Buffer(make([]byte, 0, 1<<20), 1<<20)
+		encoder := json.NewEncoder(os.Stdout)
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			if len(line) == 0 {
+				continue
+			}
+			var request rpcRequest
+			if err := json.Unmarshal(line, &request); err != nil {
+				encoder.Encode(rpcResponse{JSONRPC: "2.0", Error: &rpcError{Code: -32700, Message: "parse error: " + err.Error()}})
+				continue
+			}
+			response := rpcResponse{JSONRPC: "2.0", ID: request.ID}
+			result, err := session.dispatch(request.Method, request.Params)
+			if err != nil {
+				response.Error = &rpcError{Code: -32000, Message: err.Error()}
+			} else {
+				response.Result = result
+			}
+			encoder.Encode(response)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rpcStdioCmd)
+}
+
+func (s *rpcSession) close() {
+	if s.oracle != nil {
+		s.oracle.Close()
+		s.oracle = nil
+	}
+}
+
+func (s *rpcSession) dispatch(method string, params json.RawMessage) (any, error) {
+	switch method {
+	case "smtool.version":
+		return map[string]string{"version": Version}, nil
+
+	case "metadata.load":
+		var args struct {
+			Path string `json:"path"`
+		}
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, err
+		}
+		return loadMetadata(args.Path)
+
+	case "metadata.validate":
+		var args struct {
+			Path string `json:"path"`
+		}
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, err
+		}
+		metadata, err := loadMetadata(args.Path)
+		if err != nil {
+			return nil, err
+		}
+		var problems []string
+		for _, problem := range validateMetadata(metadata) {
+			problems = append(problems, problem.Error())
+		}
+		return map[string]any{"problems": problems}, nil
+
+	case "oracle.open":
+		var args struct {
+			Commitment string `json:"commitment"`
+			Provider   *int   `json:"provider"`
+			Difficulty string `json:"difficulty"`
+			Library    string `json:"library"`
+		}
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, err
+		}
+		commitment, err := hex.DecodeString(args.Commitment)
+		if err != nil {
+			return nil, fmt.Errorf("commitment: %w", err)
+		}
+		opts := []postrs.OracleOption{postrs.WithCommitment(commitment)}
+		if args.Provider != nil {
+			opts = append(opts, postrs.WithProviderID(*args.Provider))
+		}
+		if args.Difficulty != "" {
+			difficulty, err := hex.DecodeString(args.Difficulty)
+			if err != nil {
+				return nil, fmt.Errorf("difficulty: %w", err)
+			}
+			opts = append(opts, postrs.WithVRFDifficulty(difficulty))
+		}
+		if args.Library != "" {
+			opts = append(opts, postrs.WithLibraryPath(args.Library))
+		}
+		s.close()
+		oracle, err := postrs.NewOracle(opts...)
+		if err != nil {
+			return nil, err
+		}
+		s.oracle = oracle
+		return map[string]bool{"open": true}, nil
+
+	case "oracle.positions":
+		var args struct {
+			Start uint64 `json:"start"`
+			End   uint64 `json:"end"`
+		}
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, err
+		}
+		if s.oracle == nil {
+			return nil, fmt.Errorf("no oracle open; call oracle.open first")
+		}
+		result, err := s.oracle.Positions(args.Start, args.End)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{
+			"labels": hex.EncodeToString(result.Output),
+			"nonce":  result.Nonce,
+		}, nil
+
+	case "oracle.close":
+		s.close()
+		return map[string]bool{"closed": true}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown method %q", method)
+	}
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/leveleven/smtool/internal/pipeline"
+	"github.com/spf13/cobra"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run <plan.yaml>",
+	Short: "execute a declared sequence of smtool operations",
+	Long: `Run a plan file describing a sequence of smtool operations with
+conditionals on previous results, e.g.:
+
+  steps:
+    - name: scan
+      args: [scan, /plots]
+    - name: genonce
+      args: [genonce, --path, /plots/p1]
+      when: scan.ok
+      continueOnError: true`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		plan, err := pipeline.Load(args[0])
+		if err != nil {
+			fail(err)
+			return
+		}
+		if err := plan.Run(cmd.Context()); err != nil {
+			fail(err)
+			return
+		}
+		fmt.Println("plan completed")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/leveleven/smtool/internal/export"
+	"github.com/leveleven/smtool/internal/plots"
+	"github.com/leveleven/smtool/internal/postformat"
+	"github.com/spf13/cobra"
+)
+
+var scanOut string
+
+var scanCmd = &cobra.Command{
+	Use:   "scan [root...]",
+	Short: "inventory every plot under one or more directory trees",
+	Long: `Walk the given roots (default: the discovered data directory), find
+every postdata_metadata.json and print one line per plot: NodeID,
+numUnits, size on disk, nonce status and file count against the
+expected layout — the single inventory view a farm with dozens of
+plots needs.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		roots := args
+		if len(roots) == 0 {
+			dir, err := resolvePath("")
+			if err != nil {
+				fail(err)
+				return
+			}
+			roots = []string{dir}
+		}
+		runScan(roots)
+	},
+}
+
+func init() {
+	scanCmd.Flags().StringVar(&scanOut, "out", "",
+		"also write the inventory to this .csv or .parquet file")
+	rootCmd.AddCommand(scanCmd)
+}
+
+func runScan(roots []string) {
+	var found []plots.Plot
+	for _, root := range roots {
+		scanned, err := plots.Scan(root)
+		if err != nil {
+			fmt.Printf("%s: %v\n", root, err)
+			continue
+		}
+		found = append(found, scanned...)
+	}
+	if len(found) == 0 {
+		fmt.Println("no plots found")
+		return
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Dir < found[j].Dir })
+
+	table := &export.Table{Columns: []export.Column{
+		{Name: "dir", Kind: export.String},
+		{Name: "nodeId", Kind: export.String},
+		{Name: "numUnits", Kind: export.Int},
+		{Name: "sizeOnDisk", Kind: export.Int},
+		{Name: "nonce", Kind: export.Bool},
+		{Name: "labelFiles", Kind: export.Int},
+		{Name: "expectedFiles", Kind: export.Int},
+	}}
+
+	fmt.Printf("%-16s %8s %10s %7s %11s  %s\n", "NODEID", "UNITS", "SIZE", "NONCE", "FILES", "DIR")
+	for _, plot := range found {
+		expected := expectedLabelFiles(plot)
+		nonce := "yes"
+		if !plot.HasNonce {
+			nonce = "MISSING"
+		}
+		files := fmt.Sprintf("%d/%d", plot.LabelFiles, expected)
+		if plot.LabelFiles != expected {
+			files += " !"
+		}
+		fmt.Printf("%-16x %8d %10s %7s %11s  %s\n",
+			plot.Metadata.NodeId[:8], plot.Metadata.NumUnits,
+			formatBytes(uint64(plot.SizeOnDisk)), nonce, files, plot.Dir)
+		table.Append(plot.Dir, fmt.Sprintf("%x", plot.Metadata.NodeId),
+			int64(plot.Metadata.NumUnits), plot.SizeOnDisk, plot.HasNonce,
+			int64(plot.LabelFiles), int64(expected))
+	}
+	fmt.Printf("%d plot(s)\n", len(found))
+
+	if scanOut != "" {
+		if err := export.Write(scanOut, table); err != nil {
+			fail(err)
+			return
+		}
+		fmt.Printf("inventory written to %s\n", scanOut)
+	}
+}
+
+// expectedLabelFiles derives how many postdata_N.bin files the declared
+// geometry implies.
+func expectedLabelFiles(plot plots.Plot) int {
+	format := postformat.ForMetadata(plot.Metadata)
+	if plot.Metadata.MaxFileSize == 0 {
+		return 0
+	}
+	labelsPerFile := plot.Metadata.MaxFileSize / format.LabelSize
+	if labelsPerFile == 0 {
+		return 0
+	}
+	numLabels := uint64(plot.Metadata.NumUnits) * plot.Metadata.LabelsPerUnit
+	return int((numLabels + labelsPerFile - 1) / labelsPerFile)
+}
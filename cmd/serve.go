@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"github.com/leveleven/smtool/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveAddr    string
+	serveRoots   []string
+	serveTokens  []string
+	serveTenants []string
+	serveConfig  string
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "run a read-only HTTP server exposing plot inventory and metadata",
+	Run: func(cmd *cobra.Command, args []string) {
+		// A config file supersedes the definition flags and stays live:
+		// edits are validated and applied without a restart.
+		if serveConfig != "" {
+			config, err := daemon.LoadConfig(serveConfig)
+			if err != nil {
+				fail(err)
+				return
+			}
+			server := &daemon.Server{Addr: serveAddr, ConfigPath: serveConfig}
+			if err := server.Reload(config); err != nil {
+				fail(err)
+				return
+			}
+			if err := server.Run(cmd.Context()); err != nil {
+				fail(err)
+			}
+			return
+		}
+		if len(serveRoots) == 0 {
+			root, err := resolvePath("")
+			if err != nil {
+				fail(err)
+				return
+			}
+			serveRoots = []string{root}
+		}
+		tokens := make(map[string]daemon.Role, len(serveTokens))
+		for _, s := range serveTokens {
+			token, role, err := daemon.ParseToken(s)
+			if err != nil {
+				fail(err)
+				return
+			}
+			tokens[token] = role
+		}
+		tenants := make(map[string]*daemon.Tenant, len(serveTenants))
+		for _, s := range serveTenants {
+			token, tenant, err := daemon.ParseTenant(s)
+			if err != nil {
+				fail(err)
+				return
+			}
+			tenants[token] = tenant
+		}
+		server := &daemon.Server{Addr: serveAddr, Roots: serveRoots, Tokens: tokens, Tenants: tenants}
+		if err := server.Run(cmd.Context()); err != nil {
+			fail(err)
+		}
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:8555", "address to listen on")
+	serveCmd.Flags().StringArrayVar(&serveRoots, "root", nil,
+		"plot root to serve; repeat for multiple roots (default: discovered data directory)")
+	serveCmd.Flags().StringArrayVar(&serveTokens, "token", nil,
+		"API token as \"<token>:viewer\" or \"<token>:operator\"; repeatable (no tokens = open access)")
+	serveCmd.Flags().StringArrayVar(&serveTenants, "tenant", nil,
+		"tenant as \"<name>:<token>:<role>:<root>[,<root>][:<maxjobs>]\"; repeatable")
+	serveCmd.Flags().StringVar(&serveConfig, "config", "",
+		"YAML config file with roots/tokens/tenants; watched and hot-reloaded on change")
+	rootCmd.AddCommand(serveCmd)
+}
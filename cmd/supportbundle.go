@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/leveleven/smtool/internal/envinfo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	supportBundlePath string
+	supportBundleOut  string
+)
+
+// supportBundleMaxFileSize caps individual files in the bundle so the
+// archive stays small enough to attach to Discord/forum posts. Logs
+// larger than this are truncated to their tail.
+const supportBundleMaxFileSize = 8 << 20
+
+var supportBundleCmd = &cobra.Command{
+	Use:   "support-bundle",
+	Short: "collect diagnostics from a plot directory into a shareable archive",
+	Long: `Collect metadata, recent logs and proof files from a plot directory
+into a single zip archive sized for attaching to support requests.
+
+Key material (key.bin, identity.key, local.key) is never included.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := resolvePath(supportBundlePath)
+		if err != nil {
+			fail(err)
+			return
+		}
+		if err := writeSupportBundle(dir, supportBundleOut); err != nil {
+			fail(err)
+			return
+		}
+		fmt.Printf("wrote %s\n", supportBundleOut)
+	},
+}
+
+func init() {
+	supportBundleCmd.Flags().StringVar(&supportBundlePath, "path", "",
+		"plot directory to collect diagnostics from (default: discovered data directory)")
+	supportBundleCmd.Flags().StringVar(&supportBundleOut, "out", "", "output archive path (default smtool-bundle-<date>.zip)")
+	rootCmd.AddCommand(supportBundleCmd)
+}
+
+// bundleExcluded reports whether a file must never end up in a support
+// bundle because it contains key material.
+func bundleExcluded(name string) bool {
+	switch strings.ToLower(name) {
+	case "key.bin", "identity.key", "local.key":
+		return true
+	}
+	return strings.HasSuffix(name, ".key")
+}
+
+// bundleIncluded reports whether a file is worth collecting: metadata,
+// logs, proofs and benchmark output, but not the label data itself.
+func bundleIncluded(name string) bool {
+	switch {
+	case name == metadataFileName:
+		return true
+	case strings.HasSuffix(name, ".log"):
+		return true
+	case name == "post.bin" || strings.HasPrefix(name, "nipost_"):
+		return true
+	case name == "challenge.bin":
+		return true
+	case strings.HasSuffix(name, ".bench.json"):
+		return true
+	}
+	return false
+}
+
+// writeSupportBundle walks dir and writes the selected files into a zip
+// archive at out.
+func writeSupportBundle(dir, out string) error {
+	if out == "" {
+		out = fmt.Sprintf("smtool-bundle-%s.zip", time.Now().Format("20060102-150405"))
+	}
+	archive, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	writer := zip.NewWriter(archive)
+	defer writer.Close()
+
+	// The machine profile answers the first round of support questions
+	// before they are asked.
+	profile, err := writer.Create("environment.txt")
+	if err != nil {
+		return err
+	}
+	if _, err := profile.Write([]byte(envinfo.Profile(dir))); err != nil {
+		return err
+	}
+
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		name := info.Name()
+		if bundleExcluded(name) || !bundleIncluded(name) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		return addToBundle(writer, path, filepath.ToSlash(rel), info.Size())
+	})
+}
+
+// addToBundle copies one file into the archive, keeping only the tail
+// of files larger than supportBundleMaxFileSize.
+func addToBundle(writer *zip.Writer, path, name string, size int64) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if size > supportBundleMaxFileSize {
+		if _, err := file.Seek(size-supportBundleMaxFileSize, io.SeekStart); err != nil {
+			return err
+		}
+		name += ".tail"
+	}
+	dst, err := writer.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, file)
+	return err
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"time"
+
+	"github.com/leveleven/smtool/internal/export"
+	"github.com/leveleven/smtool/postrs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sweepPath    string
+	sweepPowBits []uint
+	sweepK1      uint32
+	sweepK2List  []uint
+	sweepThreads uint
+	sweepOut     string
+	sweepLibrary string
+)
+
+var sweepCmd = &cobra.Command{
+	Use:   "sweep",
+	Short: "generate proofs across k2pow difficulty and K2 parameter grids",
+	Long: `Research tool (testnet use): generate proofs for the same plot across
+a sweep of k2pow difficulties and K2 values, recording wall time, PoW
+value and proof size for each cell, so parameter-change proposals can
+cite real hardware numbers. Results print as a table and export via
+--out.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := resolvePath(sweepPath)
+		if err != nil {
+			fail(err)
+			return
+		}
+		if err := runSweep(cmd, dir); err != nil {
+			fail(err)
+		}
+	},
+}
+
+func init() {
+	sweepCmd.Flags().StringVar(&sweepPath, "path", "",
+		"plot directory (default: discovered data directory)")
+	sweepCmd.Flags().UintSliceVar(&sweepPowBits, "pow-bits", []uint{20, 24, 26},
+		"k2pow difficulties to sweep, as leading-zero bit counts")
+	sweepCmd.Flags().Uint32Var(&sweepK1, "k1", 26, "K1 parameter")
+	sweepCmd.Flags().UintSliceVar(&sweepK2List, "k2", []uint{37}, "K2 values to sweep")
+	sweepCmd.Flags().UintVar(&sweepThreads, "threads", 0, "prover threads (0 = libpost default)")
+	sweepCmd.Flags().StringVar(&sweepOut, "out", "",
+		"export the sweep results to this .csv or .parquet file")
+	sweepCmd.Flags().StringVar(&sweepLibrary, "libpost", "", "libpost build to prove with")
+	rootCmd.AddCommand(sweepCmd)
+}
+
+// powDifficultyBits builds a 32-byte threshold with the given number of
+// leading zero bits; more bits means a harder k2pow.
+func powDifficultyBits(bits uint) []byte {
+	difficulty := new(big.Int).Lsh(big.NewInt(1), 256-bits)
+	difficulty.Sub(difficulty, big.NewInt(1))
+	return difficulty.FillBytes(make([]byte, 32))
+}
+
+func runSweep(cmd *cobra.Command, dir string) error {
+	// The same challenge across all cells keeps the read workload
+	// identical, so differences are attributable to the parameters.
+	challenge, err := read(filepath.Join(dir, "challenge.bin"))
+	if err != nil {
+		challenge = make([]byte, 32)
+		if _, err := rand.Read(challenge); err != nil {
+			return err
+		}
+		fmt.Println("no challenge.bin; using a random challenge")
+	}
+
+	table := &export.Table{Columns: []export.Column{
+		{Name: "powBits", Kind: export.Int},
+		{Name: "k1", Kind: export.Int},
+		{Name: "k2", Kind: export.Int},
+		{Name: "seconds", Kind: export.Float},
+		{Name: "proofBytes", Kind: export.Int},
+		{Name: "pow", Kind: export.Int},
+	}}
+	fmt.Printf("%7s %4s %4s %10s %10s\n", "powBits", "k1", "k2", "time", "size")
+	for _, bits := range sweepPowBits {
+		for _, k2 := range sweepK2List {
+			if err := cmd.Context().Err(); err != nil {
+				return err
+			}
+			params := postrs.ProofParams{
+				K1:            sweepK1,
+				K2:            uint32(k2),
+				PowDifficulty: powDifficultyBits(bits),
+				Threads:       sweepThreads,
+			}
+			start := time.Now()
+			proof, err := postrs.GenerateProof(sweepLibrary, dir, challenge, params)
+			if err != nil {
+				fmt.Printf("%7d %4d %4d failed: %v\n", bits, sweepK1, k2, err)
+				continue
+			}
+			elapsed := time.Since(start)
+			size := len(proof.Indices)
+			fmt.Printf("%7d %4d %4d %10s %10s\n",
+				bits, sweepK1, k2, elapsed.Round(time.Millisecond), formatBytes(uint64(size)))
+			table.Append(int64(bits), int64(sweepK1), int64(k2),
+				elapsed.Seconds(), int64(size), int64(proof.Pow))
+		}
+	}
+
+	if sweepOut != "" {
+		if err := export.Write(sweepOut, table); err != nil {
+			return err
+		}
+		fmt.Printf("%d results written to %s\n", len(table.Rows), sweepOut)
+	}
+	return nil
+}
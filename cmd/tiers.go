@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/leveleven/smtool/internal/plots"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tiersRoot        string
+	tiersDeadline    time.Duration
+	tiersConcurrency int
+)
+
+var tiersCmd = &cobra.Command{
+	Use:   "tiers",
+	Short: "recommend storage tier moves based on measured read performance",
+	Long: `Measure random-read latency and throughput for every plot under a
+root and compare the estimated proving read time against the proving
+deadline, recommending which plots should move to faster storage and
+which can be demoted to slower tiers.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		root, err := resolvePath(tiersRoot)
+		if err != nil {
+			fail(err)
+			return
+		}
+		adviseTiers(root, tiersDeadline, tiersConcurrency)
+	},
+}
+
+func init() {
+	tiersCmd.Flags().StringVar(&tiersRoot, "root", "", "directory tree containing plots")
+	tiersCmd.Flags().DurationVar(&tiersDeadline, "deadline", 4*time.Hour,
+		"proving deadline the reads must fit into")
+	tiersCmd.Flags().IntVar(&tiersConcurrency, "concurrency", 1,
+		"how many plots prove at the same time on this host")
+	rootCmd.AddCommand(tiersCmd)
+}
+
+// plotReadProfile is the measured read behavior of one plot.
+type plotReadProfile struct {
+	plot       plots.Plot
+	latency    time.Duration // median random-read latency
+	throughput float64       // bytes/second
+	estimate   time.Duration // estimated full read during proving
+}
+
+func adviseTiers(root string, deadline time.Duration, concurrency int) {
+	found, err := plots.Scan(root)
+	if err != nil {
+		fail(err)
+		return
+	}
+	if len(found) == 0 {
+		fmt.Println("no plots found")
+		return
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var profiles []plotReadProfile
+	for _, plot := range found {
+		profile, err := measurePlot(plot)
+		if err != nil {
+			fmt.Printf("%s: %v\n", plot.Dir, err)
+			continue
+		}
+		profiles = append(profiles, *profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].estimate > profiles[j].estimate })
+
+	// With concurrent proving the disks are shared, so each plot's
+	// effective budget shrinks.
+	budget := deadline / time.Duration(concurrency)
+	fmt.Printf("proving deadline %v, concurrency %d → per-plot budget %v\n\n", deadline, concurrency, budget)
+	for _, p := range profiles {
+		verdict := "ok"
+		switch {
+		case p.estimate > budget*8/10:
+			verdict = "MOVE TO FASTER TIER (estimate within 80% of budget)"
+		case p.estimate < budget/5:
+			verdict = "can demote to slower tier"
+		}
+		fmt.Printf("%s\n  latency %v, throughput %.0f MB/s, est. read %v — %s\n",
+			p.plot.Dir, p.latency.Round(time.Microsecond), p.throughput/1e6, p.estimate.Round(time.Second), verdict)
+	}
+}
+
+// measurePlot samples random reads from the plot's largest label file.
+func measurePlot(plot plots.Plot) (*plotReadProfile, error) {
+	entries, err := filepath.Glob(filepath.Join(plot.Dir, "postdata_*.bin"))
+	if err != nil || len(entries) == 0 {
+		return nil, fmt.Errorf("no label files")
+	}
+	var target string
+	var targetSize int64
+	for _, entry := range entries {
+		if strings.HasSuffix(entry, "metadata.json") {
+			continue
+		}
+		if info, err := os.Stat(entry); err == nil && info.Size() > targetSize {
+			target, targetSize = entry, info.Size()
+		}
+	}
+	if targetSize == 0 {
+		return nil, fmt.Errorf("label files are empty")
+	}
+
+	file, err := os.Open(target)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	const samples = 64
+	const readSize = 64 * 1024
+	buf := make([]byte, readSize)
+	latencies := make([]time.Duration, 0, samples)
+	var bytesRead int64
+	begin := time.Now()
+	for i := 0; i < samples; i++ {
+		offset := rand.Int63n(maxInt64(targetSize-readSize, 1))
+		start := time.Now()
+		n, err := file.ReadAt(buf, offset)
+		if err != nil && n == 0 {
+			return nil, err
+		}
+		latencies = append(latencies, time.Since(start))
+		bytesRead += int64(n)
+	}
+	elapsed := time.Since(begin)
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	throughput := float64(bytesRead) / elapsed.Seconds()
+	return &plotReadProfile{
+		plot:       plot,
+		latency:    latencies[len(latencies)/2],
+		throughput: throughput,
+		estimate:   time.Duration(float64(plot.SizeOnDisk) / throughput * float64(time.Second)),
+	}, nil
+}
+
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
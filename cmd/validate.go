@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/leveleven/smtool/internal/postformat"
+	"github.com/leveleven/smtool/postrs"
+	"github.com/spacemeshos/post/shared"
+)
+
+// validateMetadata runs consistency checks over a plot's metadata and
+// returns a problem description per failed check. Checks that need the
+// oracle (label recomputation) are skipped when libpost is unavailable.
+func validateMetadata(metadata *shared.PostMetadata) []error {
+	var problems []error
+	if len(metadata.NodeId) != 32 {
+		problems = append(problems, fmt.Errorf("NodeId must be 32 bytes, got %d", len(metadata.NodeId)))
+	}
+	if len(metadata.CommitmentAtxId) != 32 {
+		problems = append(problems, fmt.Errorf("CommitmentAtxId must be 32 bytes, got %d", len(metadata.CommitmentAtxId)))
+	}
+	if metadata.Nonce != nil && len(metadata.NonceValue) > 0 {
+		if err := validateNonceValue(metadata); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	return problems
+}
+
+// validateNonceValue recomputes the label at metadata.Nonce and checks
+// it equals metadata.NonceValue. The two fields being edited
+// inconsistently by other tools is a common corruption case.
+func validateNonceValue(metadata *shared.PostMetadata) error {
+	wo, err := postrs.NewOracle(
+		postrs.WithProviderID(postrs.CPUProviderID),
+		postrs.WithCommitment(postrs.CommitmentBytes(metadata.NodeId, metadata.CommitmentAtxId)),
+	)
+	if err != nil {
+		return fmt.Errorf("cannot recompute nonce label: %w", err)
+	}
+	defer wo.Close()
+
+	result, err := wo.Positions(*metadata.Nonce, *metadata.Nonce)
+	if err != nil {
+		return fmt.Errorf("recompute label at nonce %d: %w", *metadata.Nonce, err)
+	}
+	label := result.Output[:postformat.ForMetadata(metadata).LabelSize]
+	if !bytes.Equal(label, metadata.NonceValue) {
+		return fmt.Errorf("NonceValue %x does not match recomputed label %x at nonce %d",
+			[]byte(metadata.NonceValue), label, *metadata.Nonce)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/leveleven/smtool/internal/audit"
+	"github.com/leveleven/smtool/internal/gpulock"
+	"github.com/leveleven/smtool/internal/postformat"
+	"github.com/leveleven/smtool/internal/usage"
+	"github.com/leveleven/smtool/postrs"
+	"github.com/spacemeshos/post/shared"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyDataPath     string
+	verifyDataFraction float64
+	verifyDataProvider int
+)
+
+var verifyDataCmd = &cobra.Command{
+	Use:   "verifyData",
+	Short: "recompute labels and compare them with the postdata files",
+	Long: `Recompute a sample of the plot's labels on a provider and compare
+them byte-for-byte with what is on disk, reporting the file and byte
+offset of every corrupted label. --fraction 1 recomputes the whole
+plot; the default spot-checks it in minutes the way post-rs
+verify-data does.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := resolvePath(verifyDataPath)
+		if err != nil {
+			fail(err)
+			return
+		}
+		verifyData(dir)
+	},
+}
+
+func init() {
+	verifyDataCmd.Flags().StringVar(&verifyDataPath, "path", "",
+		"plot directory containing postdata_metadata.json (default: discovered data directory)")
+	verifyDataCmd.Flags().Float64Var(&verifyDataFraction, "fraction", 0.001,
+		"fraction of labels to verify (1 = the whole plot)")
+	verifyDataCmd.Flags().IntVar(&verifyDataProvider, "provider", postrs.CPUProviderID,
+		"compute provider ID to recompute with (see smtool providers)")
+	rootCmd.AddCommand(verifyDataCmd)
+}
+
+func verifyData(dir string) {
+	metadata, err := loadMetadata(dir)
+	if err != nil {
+		fail(err)
+		return
+	}
+	format := postformat.ForMetadata(metadata)
+	numLabels := uint64(metadata.NumUnits) * metadata.LabelsPerUnit
+	if numLabels == 0 {
+		fmt.Println("plot has no labels")
+		return
+	}
+	if metadata.MaxFileSize/format.LabelSize == 0 {
+		fail(fmt.Errorf("metadata reports MaxFileSize %d, smaller than one label", metadata.MaxFileSize))
+		return
+	}
+
+	releaseDevice, err := gpulock.Acquire(fmt.Sprint(verifyDataProvider), true)
+	if err != nil {
+		fail(err)
+		return
+	}
+	defer releaseDevice()
+
+	wo, err := postrs.NewOracle(
+		postrs.WithProviderID(verifyDataProvider),
+		postrs.WithCommitment(postrs.CommitmentBytes(metadata.NodeId, metadata.CommitmentAtxId)),
+	)
+	if err != nil {
+		fail(err)
+		return
+	}
+	defer wo.Close()
+
+	startTime := time.Now()
+	var checked, corrupted uint64
+	defer func() {
+		usage.Collect(startTime).Print("labels", checked)
+	}()
+
+	if verifyDataFraction >= 1 {
+		checked, corrupted = verifyAllLabels(dir, metadata, format, wo, numLabels)
+	} else {
+		checked, corrupted = verifySampledLabels(dir, metadata, format, wo, numLabels)
+	}
+	audit.Record("verifyData", dir, map[string]string{
+		"checked":   fmt.Sprint(checked),
+		"corrupted": fmt.Sprint(corrupted),
+	}, nil)
+	if corrupted == 0 {
+		fmt.Printf("verified %d labels, no corruption\n", checked)
+	} else {
+		fmt.Printf("verified %d labels, %d CORRUPTED\n", checked, corrupted)
+	}
+}
+
+// verifyDataBatchSize is the contiguous range recomputed per oracle
+// call during a full scan.
+const verifyDataBatchSize = 64 * 1024
+
+// verifyAllLabels recomputes every label in contiguous batches and
+// compares whole ranges, the fast path for --fraction 1.
+func verifyAllLabels(dir string, metadata *shared.PostMetadata, format postformat.Format, wo *postrs.WorkOracle, numLabels uint64) (checked, corrupted uint64) {
+	labelsPerFile := metadata.MaxFileSize / format.LabelSize
+	for start := uint64(0); start < numLabels; start += verifyDataBatchSize {
+		end := start + verifyDataBatchSize - 1
+		if end >= numLabels {
+			end = numLabels - 1
+		}
+		result, err := wo.Positions(start, end)
+		if err != nil {
+			fail(err)
+			return
+		}
+		for position := start; position <= end; position++ {
+			want := format.Label(result.Output, start, position)
+			if compareDiskLabel(dir, format, labelsPerFile, position, want) {
+				corrupted++
+			}
+			checked++
+		}
+	}
+	return
+}
+
+// samplePositions draws count random positions below numLabels, sorted
+// ascending with duplicates removed. The dedup matters: the oracle
+// rejects a repeated position as an overlapping range, and at
+// spot-check sample sizes a collision is practically certain.
+func samplePositions(numLabels, count uint64) []uint64 {
+	positions := make([]uint64, count)
+	for i := range positions {
+		positions[i] = rand.Uint64() % numLabels
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i] < positions[j] })
+	unique := positions[:0]
+	for i, position := range positions {
+		if i == 0 || position != positions[i-1] {
+			unique = append(unique, position)
+		}
+	}
+	return unique
+}
+
+// verifySampledLabels spot-checks a random sample, ascending so one
+// oracle serves the whole run.
+func verifySampledLabels(dir string, metadata *shared.PostMetadata, format postformat.Format, wo *postrs.WorkOracle, numLabels uint64) (checked, corrupted uint64) {
+	labelsPerFile := metadata.MaxFileSize / format.LabelSize
+	count := uint64(float64(numLabels) * verifyDataFraction)
+	if count == 0 {
+		count = 1
+	}
+	for _, position := range samplePositions(numLabels, count) {
+		result, err := wo.Positions(position, position)
+		if err != nil {
+			fail(err)
+			return
+		}
+		if compareDiskLabel(dir, format, labelsPerFile, position, result.Output[:format.LabelSize]) {
+			corrupted++
+		}
+		checked++
+	}
+	return
+}
+
+// compareDiskLabel reads the label at position and reports (and
+// returns) whether it differs from want.
+func compareDiskLabel(dir string, format postformat.Format, labelsPerFile, position uint64, want []byte) bool {
+	name := fmt.Sprintf("postdata_%d.bin", position/labelsPerFile)
+	offset := int64((position % labelsPerFile) * format.LabelSize)
+	handle, err := os.Open(filepath.Join(dir, name))
+	if err != nil {
+		fmt.Printf("position %d: %v\n", position, err)
+		return true
+	}
+	defer handle.Close()
+	disk := make([]byte, format.LabelSize)
+	if _, err := handle.ReadAt(disk, offset); err != nil {
+		fmt.Printf("position %d: %s offset %d: %v\n", position, name, offset, err)
+		return true
+	}
+	if !bytes.Equal(disk, want) {
+		fmt.Printf("corrupted label at %s offset %d (position %d): disk %x, recomputed %x\n",
+			name, offset, position, disk, want)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import "testing"
+
+func TestSamplePositions(t *testing.T) {
+	// 1000 draws from 100 values guarantee duplicates; the result must
+	// still be strictly ascending or the oracle rejects the scan.
+	positions := samplePositions(100, 1000)
+	if len(positions) == 0 || len(positions) > 100 {
+		t.Fatalf("samplePositions returned %d positions from a range of 100", len(positions))
+	}
+	for i, position := range positions {
+		if position >= 100 {
+			t.Errorf("position %d is outside the plot", position)
+		}
+		if i > 0 && position <= positions[i-1] {
+			t.Errorf("positions not strictly ascending: %d after %d", position, positions[i-1])
+		}
+	}
+
+	if got := samplePositions(1<<40, 1); len(got) != 1 {
+		t.Errorf("samplePositions(1<<40, 1) returned %d positions, want 1", len(got))
+	}
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnpackIndices(t *testing.T) {
+	tests := []struct {
+		name         string
+		packed       []byte
+		bitsPerIndex int
+		want         []uint64
+	}{
+		{
+			name:         "zero bits",
+			packed:       []byte{0x21},
+			bitsPerIndex: 0,
+			want:         nil,
+		},
+		{
+			name:         "nibbles",
+			packed:       []byte{0x21, 0x43},
+			bitsPerIndex: 4,
+			want:         []uint64{1, 2, 3, 4},
+		},
+		{
+			name:         "whole bytes",
+			packed:       []byte{0xff, 0x00, 0x7f},
+			bitsPerIndex: 8,
+			want:         []uint64{255, 0, 127},
+		},
+		{
+			name: "indices straddling byte boundaries",
+			// 10-bit indices 513 and 2, least significant bits first:
+			// 513 = 0b10_00000001, then 2 starting at bit 10.
+			packed:       []byte{0x01, 0x0a, 0x00},
+			bitsPerIndex: 10,
+			want:         []uint64{513, 2},
+		},
+		{
+			name:         "trailing partial index is dropped",
+			packed:       []byte{0x21},
+			bitsPerIndex: 6,
+			want:         []uint64{0x21},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := unpackIndices(tt.packed, tt.bitsPerIndex)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unpackIndices(%x, %d) = %v, want %v",
+					tt.packed, tt.bitsPerIndex, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/leveleven/smtool/postrs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyProofMetadata string
+	verifyProofLibs     []string
+)
+
+var verifyProofCmd = &cobra.Command{
+	Use:   "verifyproof <post.bin>",
+	Short: "verify a POST proof, optionally cross-checking several libpost versions",
+	Long: `Verify a scale-encoded POST proof against its proof metadata.
+
+When --libpost is given more than once the proof is run through every
+listed libpost build and the verdicts are compared. A disagreement
+between versions is reported explicitly, which is the interesting
+outcome when chasing consensus-affecting verifier bugs.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		proof, err := read(args[0])
+		if err != nil {
+			fail(err)
+			return
+		}
+		metadata, err := read(verifyProofMetadata)
+		if err != nil {
+			fail(err)
+			return
+		}
+		crossCheckProof(proof, metadata, verifyProofLibs)
+	},
+}
+
+func init() {
+	verifyProofCmd.Flags().StringVar(&verifyProofMetadata, "metadata", "", "path to the scale-encoded proof metadata file")
+	verifyProofCmd.Flags().StringArrayVar(&verifyProofLibs, "libpost", nil,
+		"path to a libpost build to verify with; repeat to cross-check several versions (default: system libpost)")
+	verifyProofCmd.MarkFlagRequired("metadata")
+	rootCmd.AddCommand(verifyProofCmd)
+}
+
+// verdict is the outcome of one verifier run.
+type verdict struct {
+	path    string
+	version string
+	err     error
+}
+
+// crossCheckProof verifies the proof with every listed libpost build
+// (or the system one when none is given) and prints a per-version
+// verdict plus a disagreement summary.
+func crossCheckProof(proof, metadata []byte, libs []string) {
+	if len(libs) == 0 {
+		libs = []string{postrs.DefaultLibrary}
+	}
+
+	verdicts := make([]verdict, 0, len(libs))
+	for _, lib := range libs {
+		verifier, err := postrs.NewVerifier(lib)
+		if err != nil {
+			fail(err)
+			return
+		}
+		verdicts = append(verdicts, verdict{
+			path:    verifier.Path(),
+			version: verifier.Version(),
+			err:     verifier.Verify(proof, metadata),
+		})
+		verifier.Close()
+	}
+
+	valid, invalid := 0, 0
+	for _, v := range verdicts {
+		if v.err == nil {
+			fmt.Printf("%s (%s): valid\n", v.path, v.version)
+			valid++
+		} else {
+			fmt.Printf("%s (%s): %v\n", v.path, v.version, v.err)
+			invalid++
+		}
+	}
+
+	switch {
+	case invalid == 0:
+		fmt.Println("proof is valid")
+	case valid == 0:
+		fmt.Println("proof is invalid")
+	default:
+		fmt.Printf("VERIFIERS DISAGREE: %d valid, %d invalid — this likely indicates a verifier bug, please report it\n", valid, invalid)
+	}
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/leveleven/smtool/internal/datadir"
+	"github.com/leveleven/smtool/internal/postformat"
+	"github.com/spacemeshos/post/shared"
+	"github.com/spf13/cobra"
+	"golang.org/x/sys/unix"
+)
+
+// defaultLabelsPerUnit matches the mainnet unit size: 64 GiB worth of
+// labels in the current format.
+var defaultLabelsPerUnit = uint64(64<<30) / postformat.Current.LabelSize
+
+var wizardCmd = &cobra.Command{
+	Use:   "wizard",
+	Short: "interactively set up a new plot from scratch",
+	Long: `Walk through data directory selection, identity creation, size
+planning and metadata creation for a first plot, then print the exact
+commands and node configuration to finish the job.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runWizard(); err != nil {
+			fail(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(wizardCmd)
+}
+
+// ask prompts with a default value.
+func ask(reader *bufio.Reader, prompt, defaultValue string) string {
+	if defaultValue != "" {
+		fmt.Printf("%s [%s]: ", prompt, defaultValue)
+	} else {
+		fmt.Printf("%s: ", prompt)
+	}
+	answer, _ := reader.ReadString('\n')
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return defaultValue
+	}
+	return answer
+}
+
+func runWizard() error {
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Println("smtool setup wizard — answer a few questions to prepare a new plot.")
+
+	// Data directory.
+	suggestion := ""
+	if candidates := datadir.Candidates(); len(candidates) > 0 {
+		suggestion = filepath.Join(candidates[0], "post")
+	}
+	dir := ask(reader, "plot directory", suggestion)
+	if dir == "" {
+		return fmt.Errorf("a plot directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	// Identity.
+	keyPath := filepath.Join(dir, "identity.key")
+	var publicKey ed25519.PublicKey
+	if data, err := os.ReadFile(keyPath); err == nil && len(data) >= ed25519.SeedSize {
+		publicKey = ed25519.NewKeyFromSeed(data[:ed25519.SeedSize]).Public().(ed25519.PublicKey)
+		fmt.Printf("using existing identity %x\n", publicKey)
+	} else {
+		seed := make([]byte, ed25519.SeedSize)
+		if _, err := rand.Read(seed); err != nil {
+			return err
+		}
+		if err := os.WriteFile(keyPath, seed, 0o600); err != nil {
+			return err
+		}
+		publicKey = ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)
+		fmt.Printf("created new identity %x (key at %s — back it up!)\n", publicKey, keyPath)
+	}
+
+	// Size planning against the actual free space.
+	var stat unix.Statfs_t
+	if err := unix.Statfs(dir, &stat); err == nil {
+		free := stat.Bavail * uint64(stat.Bsize)
+		fmt.Printf("free space on target filesystem: %d GiB (one unit = 64 GiB)\n", free>>30)
+	}
+	numUnitsAnswer := ask(reader, "number of units to plot", "4")
+	numUnits, err := strconv.ParseUint(numUnitsAnswer, 10, 32)
+	if err != nil || numUnits == 0 {
+		return fmt.Errorf("invalid unit count %q", numUnitsAnswer)
+	}
+	fmt.Printf("plot will occupy %d GiB\n", numUnits*64)
+
+	// Commitment ATX.
+	commitmentHex := ask(reader, "commitment ATX id (hex, empty to fill in later)", "")
+	var commitment []byte
+	if commitmentHex != "" {
+		commitment, err = hex.DecodeString(commitmentHex)
+		if err != nil || len(commitment) != 32 {
+			return fmt.Errorf("commitment ATX id must be 64 hex characters")
+		}
+	}
+
+	metadata := &shared.PostMetadata{
+		NodeId:          publicKey,
+		CommitmentAtxId: commitment,
+		NumUnits:        uint32(numUnits),
+		LabelsPerUnit:   defaultLabelsPerUnit,
+		MaxFileSize:     4 << 30,
+	}
+	if err := saveMetadata(dir, metadata); err != nil {
+		return err
+	}
+	fmt.Printf("wrote %s\n\n", filepath.Join(dir, metadataFileName))
+
+	fmt.Println("next steps:")
+	step := 1
+	if commitment == nil {
+		fmt.Printf("  %d. fetch the current commitment ATX id and add it to the metadata\n", step)
+		step++
+	}
+	fmt.Printf("  %d. plot the data (postcli or a GPU initializer) into %s\n", step, dir)
+	step++
+	fmt.Printf("  %d. smtool genonce --path %s\n", step, dir)
+	step++
+	fmt.Printf("  %d. point the node at the plot with this config snippet:\n\n", step)
+	fmt.Printf(`     "smeshing": {
+       "smeshing-start": true,
+       "smeshing-opts": {
+         "smeshing-opts-datadir": %q,
+         "smeshing-opts-numunits": %d
+       }
+     }
+`, dir, numUnits)
+	return nil
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/leveleven/smtool/internal/postformat"
+	"github.com/spacemeshos/post/shared"
+	"golang.org/x/sys/unix"
+)
+
+// zeroRegion is a run of zero bytes (or a filesystem hole) inside a
+// label file, expressed as a label range for targeted repair.
+type zeroRegion struct {
+	File       string
+	FirstLabel uint64
+	LastLabel  uint64
+	Hole       bool
+}
+
+// minZeroRun is the smallest run of zero bytes worth reporting; real
+// label data contains short zero stretches by chance, but 64KiB of
+// zeros is the signature of failed GPU plotting.
+const minZeroRun = 64 << 10
+
+// scanZeroRegions checks every postdata file for filesystem holes
+// (cheap, via SEEK_HOLE) and long literal zero runs (one sequential
+// read).
+func scanZeroRegions(dir string, metadata *shared.PostMetadata) ([]zeroRegion, error) {
+	format := postformat.ForMetadata(metadata)
+	labelsPerFile := metadata.MaxFileSize / format.LabelSize
+
+	files, err := filepath.Glob(filepath.Join(dir, "postdata_*.bin"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var regions []zeroRegion
+	for _, path := range files {
+		index, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "postdata_"), ".bin"), 10, 64)
+		if err != nil {
+			continue
+		}
+		base := index * labelsPerFile
+
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		toLabels := func(start, end int64, hole bool) zeroRegion {
+			return zeroRegion{
+				File:       filepath.Base(path),
+				FirstLabel: base + uint64(start)/format.LabelSize,
+				LastLabel:  base + uint64(end-1)/format.LabelSize,
+				Hole:       hole,
+			}
+		}
+
+		regions = append(regions, scanHoles(file, toLabels)...)
+		found, err := scanZeroRuns(file, toLabels)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("scan %s: %w", path, err)
+		}
+		regions = append(regions, found...)
+	}
+	return regions, nil
+}
+
+// scanHoles walks the file's SEEK_HOLE/SEEK_DATA map. Filesystems
+// without hole support report one hole at EOF, which is skipped.
+func scanHoles(file *os.File, toLabels func(start, end int64, hole bool) zeroRegion) []zeroRegion {
+	info, err := file.Stat()
+	if err != nil {
+		return nil
+	}
+	size := info.Size()
+	var regions []zeroRegion
+	offset := int64(0)
+	for offset < size {
+		hole, err := unix.Seek(int(file.Fd()), offset, unix.SEEK_HOLE)
+		if err != nil || hole >= size {
+			break
+		}
+		data, err := unix.Seek(int(file.Fd()), hole, unix.SEEK_DATA)
+		if err != nil {
+			data = size
+		}
+		regions = append(regions, toLabels(hole, data, true))
+		offset = data
+	}
+	return regions
+}
+
+// scanZeroRuns reads the whole file looking for literal zero runs of at
+// least minZeroRun bytes.
+func scanZeroRuns(file *os.File, toLabels func(start, end int64, hole bool) zeroRegion) ([]zeroRegion, error) {
+	if _, err := file.Seek(0, 0); err != nil {
+		return nil, err
+	}
+	var regions []zeroRegion
+	buf := make([]byte, 1<<20)
+	var offset, runStart int64
+	inRun := false
+	flush := func(end int64) {
+		if inRun && end-runStart >= minZeroRun {
+			regions = append(regions, toLabels(runStart, end, false))
+		}
+		inRun = false
+	}
+	for {
+		n, err := file.Read(buf)
+		chunk := buf[:n]
+		for len(chunk) > 0 {
+			if !inRun {
+				i := bytes.IndexByte(chunk, 0)
+				if i < 0 {
+					offset += int64(len(chunk))
+					break
+				}
+				offset += int64(i)
+				chunk = chunk[i:]
+				runStart = offset
+				inRun = true
+			}
+			i := bytes.IndexFunc(chunk, func(r rune) bool { return r != 0 })
+			if i < 0 {
+				offset += int64(len(chunk))
+				break
+			}
+			offset += int64(i)
+			chunk = chunk[i:]
+			flush(offset)
+		}
+		if err != nil {
+			flush(offset)
+			if errors.Is(err, io.EOF) {
+				return regions, nil
+			}
+			return regions, err
+		}
+	}
+}
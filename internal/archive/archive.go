@@ -0,0 +1,267 @@
+// Package archive implements smtool's cold-storage container for
+// decommissioned plots: a sequential, chunked format with per-chunk
+// digests and zstd compression applied only where it helps, so plots
+// can be parked on tape or object storage and restored verifiably.
+package archive
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// magic identifies version 1 of the container format.
+var magic = []byte("SMARCH01")
+
+// ChunkSize is the archival unit; digests and compression decisions
+// are per chunk so partial corruption is locatable.
+const ChunkSize = 16 << 20
+
+// fileHeader precedes every archived file.
+type fileHeader struct {
+	// End marks the archive trailer instead of a file.
+	End  bool   `json:"end,omitempty"`
+	Name string `json:"name,omitempty"`
+	Size int64  `json:"size,omitempty"`
+}
+
+// chunkHeader precedes every chunk payload.
+type chunkHeader struct {
+	// Size is the uncompressed chunk size.
+	Size int64 `json:"size"`
+	// Stored is the on-archive payload size.
+	Stored int64 `json:"stored"`
+	// Zstd reports whether the payload is zstd-compressed.
+	Zstd   bool   `json:"zstd,omitempty"`
+	SHA256 string `json:"sha256"`
+}
+
+// writeHeader writes a length-prefixed JSON header.
+func writeHeader(w io.Writer, header any) error {
+	encoded, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(encoded)))
+	if _, err := w.Write(length[:]); err != nil {
+		return err
+	}
+	_, err = w.Write(encoded)
+	return err
+}
+
+// readHeader reads a length-prefixed JSON header into dst.
+func readHeader(r io.Reader, dst any) error {
+	var length [4]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return err
+	}
+	n := binary.BigEndian.Uint32(length[:])
+	if n > 1<<20 {
+		return fmt.Errorf("implausible header size %d", n)
+	}
+	encoded := make([]byte, n)
+	if _, err := io.ReadFull(r, encoded); err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, dst)
+}
+
+// Create archives every file under dir (key material excluded) into
+// out.
+func Create(dir, out string) error {
+	output, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+	if _, err := output.Write(magic); err != nil {
+		return err
+	}
+
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		return err
+	}
+	defer encoder.Close()
+
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		if strings.HasSuffix(info.Name(), ".key") || info.Name() == "key.bin" {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("archiving %s (%d bytes)\n", rel, info.Size())
+		return addFile(output, encoder, path, filepath.ToSlash(rel), info.Size())
+	})
+	if err != nil {
+		return err
+	}
+	if err := writeHeader(output, fileHeader{End: true}); err != nil {
+		return err
+	}
+	return output.Sync()
+}
+
+func addFile(output io.Writer, encoder *zstd.Encoder, path, name string, size int64) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := writeHeader(output, fileHeader{Name: name, Size: size}); err != nil {
+		return err
+	}
+	buf := make([]byte, ChunkSize)
+	for {
+		n, err := io.ReadFull(file, buf)
+		if n == 0 {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		chunk := buf[:n]
+		sum := sha256.Sum256(chunk)
+
+		payload := chunk
+		compressed := encoder.EncodeAll(chunk, nil)
+		useZstd := len(compressed) < len(chunk)*95/100
+		if useZstd {
+			payload = compressed
+		}
+		header := chunkHeader{
+			Size:   int64(n),
+			Stored: int64(len(payload)),
+			Zstd:   useZstd,
+			SHA256: hex.EncodeToString(sum[:]),
+		}
+		if err := writeHeader(output, header); err != nil {
+			return err
+		}
+		if _, err := output.Write(payload); err != nil {
+			return err
+		}
+		if err == io.ErrUnexpectedEOF || err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// walk reads the archive sequentially, calling handle for every chunk.
+// handle receives the verified, decompressed chunk data.
+func walk(path string, handle func(file fileHeader, offset int64, chunk []byte) error) error {
+	input, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	header := make([]byte, len(magic))
+	if _, err := io.ReadFull(input, header); err != nil || string(header) != string(magic) {
+		return fmt.Errorf("%s is not an smtool archive", path)
+	}
+
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		return err
+	}
+	defer decoder.Close()
+
+	for {
+		var file fileHeader
+		if err := readHeader(input, &file); err != nil {
+			return fmt.Errorf("truncated archive: %w", err)
+		}
+		if file.End {
+			return nil
+		}
+		for offset := int64(0); offset < file.Size; {
+			var chunk chunkHeader
+			if err := readHeader(input, &chunk); err != nil {
+				return fmt.Errorf("%s: truncated chunk header: %w", file.Name, err)
+			}
+			payload := make([]byte, chunk.Stored)
+			if _, err := io.ReadFull(input, payload); err != nil {
+				return fmt.Errorf("%s: truncated chunk at offset %d: %w", file.Name, offset, err)
+			}
+			data := payload
+			if chunk.Zstd {
+				data, err = decoder.DecodeAll(payload, nil)
+				if err != nil {
+					return fmt.Errorf("%s: chunk at offset %d: %w", file.Name, offset, err)
+				}
+			}
+			sum := sha256.Sum256(data)
+			if hex.EncodeToString(sum[:]) != chunk.SHA256 {
+				return fmt.Errorf("%s: chunk at offset %d failed digest verification", file.Name, offset)
+			}
+			if err := handle(file, offset, data); err != nil {
+				return err
+			}
+			offset += chunk.Size
+		}
+	}
+}
+
+// Extract restores an archive into dir.
+func Extract(path, dir string) error {
+	var current *os.File
+	var currentName string
+	defer func() {
+		if current != nil {
+			current.Close()
+		}
+	}()
+	return walk(path, func(file fileHeader, offset int64, chunk []byte) error {
+		if file.Name != currentName {
+			if current != nil {
+				if err := current.Close(); err != nil {
+					return err
+				}
+			}
+			target := filepath.Join(dir, filepath.FromSlash(file.Name))
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			f, err := os.Create(target)
+			if err != nil {
+				return err
+			}
+			current, currentName = f, file.Name
+			fmt.Printf("extracting %s (%d bytes)\n", file.Name, file.Size)
+		}
+		_, err := current.WriteAt(chunk, offset)
+		return err
+	})
+}
+
+// Verify checks every chunk digest without writing anything.
+func Verify(path string) error {
+	files := make(map[string]int64)
+	if err := walk(path, func(file fileHeader, offset int64, chunk []byte) error {
+		files[file.Name] += int64(len(chunk))
+		return nil
+	}); err != nil {
+		return err
+	}
+	for name, size := range files {
+		fmt.Printf("ok %s (%d bytes)\n", name, size)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+// Package audit records every mutating operation into an append-only
+// local log (and optionally syslog), which matters once serve/agent
+// modes allow remote operation.
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/syslog"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+)
+
+// Entry is one audit record, stored as a JSON line.
+type Entry struct {
+	Time   time.Time         `json:"time"`
+	User   string            `json:"user"`
+	Op     string            `json:"op"`
+	Target string            `json:"target"`
+	Params map[string]string `json:"params,omitempty"`
+	Result string            `json:"result"`
+}
+
+// UseSyslog additionally mirrors records to syslog when set.
+var UseSyslog bool
+
+// Path returns the audit log location, creating its directory.
+func Path() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(home, ".smtool")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "audit.log"), nil
+}
+
+// Record appends an entry for a mutating operation. result nil is
+// logged as "ok". Audit failures are reported but never fail the
+// operation itself.
+func Record(op, target string, params map[string]string, result error) {
+	entry := Entry{
+		Time:   time.Now().UTC(),
+		Op:     op,
+		Target: target,
+		Params: params,
+		Result: "ok",
+	}
+	if u, err := user.Current(); err == nil {
+		entry.User = u.Username
+	}
+	if result != nil {
+		entry.Result = result.Error()
+	}
+
+	path, err := Path()
+	if err != nil {
+		fmt.Printf("audit: %v\n", err)
+		return
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		fmt.Printf("audit: %v\n", err)
+		return
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		fmt.Printf("audit: %v\n", err)
+		return
+	}
+	defer file.Close()
+	fmt.Fprintln(file, string(line))
+
+	if UseSyslog {
+		if writer, err := syslog.New(syslog.LOG_NOTICE|syslog.LOG_USER, "smtool"); err == nil {
+			writer.Notice(string(line))
+			writer.Close()
+		}
+	}
+}
+
+// ReadAll returns every entry in the log, oldest first.
+func ReadAll() ([]Entry, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for decoder.More() {
+		var entry Entry
+		if err := decoder.Decode(&entry); err != nil {
+			break // tolerate a torn final line
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
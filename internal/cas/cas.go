@@ -0,0 +1,147 @@
+// Package cas is a local content-addressed store for chain objects
+// (ATXs, ballots, proofs) fetched from public nodes. Objects are keyed
+// by the SHA-256 of their bytes, so a forensic session can refetch the
+// same object for free and keep working offline afterwards; a TTL-based
+// garbage collection keeps the store from growing forever.
+package cas
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Entry describes one cached object.
+type Entry struct {
+	Kind string
+	Key  string
+	Size int64
+	// Age is the time since the object was cached (mtime-based; Get
+	// refreshes it, so Age is really time since last use).
+	Age time.Duration
+}
+
+// root returns the cache directory, mirroring the audit log's location
+// convention.
+func root() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".smtool", "cache"), nil
+}
+
+// objectPath shards objects by the first key byte to keep directories
+// small.
+func objectPath(base, kind, key string) string {
+	return filepath.Join(base, kind, key[:2], key)
+}
+
+// Put stores data under its content hash and returns the key.
+func Put(kind string, data []byte) (string, error) {
+	base, err := root()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	key := hex.EncodeToString(sum[:])
+	path := objectPath(base, kind, key)
+	if _, err := os.Stat(path); err == nil {
+		return key, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// Get returns the cached object, or os.ErrNotExist when absent. A hit
+// bumps the mtime so actively used objects survive GC.
+func Get(kind, key string) ([]byte, error) {
+	base, err := root()
+	if err != nil {
+		return nil, err
+	}
+	if len(key) < 2 {
+		return nil, fmt.Errorf("invalid cache key %q", key)
+	}
+	path := objectPath(base, kind, key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != key {
+		os.Remove(path)
+		return nil, fmt.Errorf("cached object %s is corrupt; removed", key)
+	}
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return data, nil
+}
+
+// List returns every cached object, newest first within a kind.
+func List() ([]Entry, error) {
+	base, err := root()
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	err = filepath.Walk(base, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(base, path)
+		if err != nil {
+			return err
+		}
+		kind := filepath.Dir(filepath.Dir(rel))
+		entries = append(entries, Entry{
+			Kind: filepath.ToSlash(kind),
+			Key:  info.Name(),
+			Size: info.Size(),
+			Age:  time.Since(info.ModTime()),
+		})
+		return nil
+	})
+	return entries, err
+}
+
+// GC removes objects unused for longer than ttl and returns how many
+// were removed and how many bytes were freed.
+func GC(ttl time.Duration) (removed int, freed int64, err error) {
+	base, err := root()
+	if err != nil {
+		return 0, 0, err
+	}
+	err = filepath.Walk(base, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		if info.IsDir() || time.Since(info.ModTime()) < ttl {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed++
+		freed += info.Size()
+		return nil
+	})
+	return removed, freed, err
+}
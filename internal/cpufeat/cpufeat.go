@@ -0,0 +1,103 @@
+// Package cpufeat reports the CPU features that dominate CPU-provider
+// plotting performance. Labels are AES-derived, so a machine without
+// AES-NI (or a VM whose hypervisor masks it) plots an order of
+// magnitude slower — a fact worth surfacing before someone waits a week
+// to discover it.
+package cpufeat
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// Report describes the detected feature set.
+type Report struct {
+	// Model is the CPU model name, for display.
+	Model string `json:"model,omitempty"`
+	// AESNI, AVX2 and AVX512 report the instruction set extensions the
+	// CPU provider benefits from, in decreasing order of importance.
+	AESNI  bool `json:"aesni"`
+	AVX2   bool `json:"avx2"`
+	AVX512 bool `json:"avx512"`
+	// Hypervisor reports whether the kernel sees a hypervisor, meaning
+	// missing features may be masked by VM configuration rather than
+	// absent from the silicon.
+	Hypervisor bool `json:"hypervisor"`
+	// Known is false when detection is unsupported on this OS/arch.
+	Known bool `json:"known"`
+}
+
+// Detect inspects the running CPU. Only linux/amd64 exposes the flags
+// smtool cares about in a portable place (/proc/cpuinfo); elsewhere the
+// report is marked unknown rather than guessed.
+func Detect() Report {
+	if runtime.GOOS != "linux" || runtime.GOARCH != "amd64" {
+		return Report{}
+	}
+	raw, err := os.ReadFile("/proc/cpuinfo")
+	if err != nil {
+		return Report{}
+	}
+	report := Report{Known: true}
+	for _, line := range strings.Split(string(raw), "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		switch key {
+		case "model name":
+			if report.Model == "" {
+				report.Model = value
+			}
+		case "flags":
+			flags := make(map[string]bool)
+			for _, flag := range strings.Fields(value) {
+				flags[flag] = true
+			}
+			report.AESNI = flags["aes"]
+			report.AVX2 = flags["avx2"]
+			report.AVX512 = flags["avx512f"]
+			report.Hypervisor = flags["hypervisor"]
+			return report
+		}
+	}
+	return report
+}
+
+// Warnings returns human-readable performance warnings for the report.
+func (r Report) Warnings() []string {
+	if !r.Known {
+		return nil
+	}
+	var warnings []string
+	if !r.AESNI {
+		msg := "CPU reports no AES-NI: CPU plotting will be roughly 10x slower than on comparable hardware"
+		if r.Hypervisor {
+			msg += " — a hypervisor is present, so the feature may be masked by the VM's CPU model (try host-passthrough)"
+		}
+		warnings = append(warnings, msg)
+	}
+	if r.AESNI && !r.AVX2 {
+		warnings = append(warnings, "CPU reports no AVX2: CPU plotting throughput will be reduced")
+	}
+	return warnings
+}
+
+// String summarizes the report on one line.
+func (r Report) String() string {
+	if !r.Known {
+		return "cpu features: unknown on this platform"
+	}
+	onOff := func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "no"
+	}
+	return fmt.Sprintf("aes-ni: %s, avx2: %s, avx-512: %s, hypervisor: %s",
+		onOff(r.AESNI), onOff(r.AVX2), onOff(r.AVX512), onOff(r.Hypervisor))
+}
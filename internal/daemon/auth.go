@@ -0,0 +1,102 @@
+package daemon
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Role determines what a token may do against the daemon.
+type Role string
+
+const (
+	// RoleViewer may read state but never trigger jobs.
+	RoleViewer Role = "viewer"
+	// RoleOperator may additionally run mutating/job endpoints.
+	RoleOperator Role = "operator"
+)
+
+// ParseToken parses a "token:role" flag value.
+func ParseToken(s string) (token string, role Role, err error) {
+	value, roleName, found := strings.Cut(s, ":")
+	if !found || value == "" {
+		return "", "", fmt.Errorf("invalid token %q, want \"<token>:viewer\" or \"<token>:operator\"", s)
+	}
+	switch Role(roleName) {
+	case RoleViewer, RoleOperator:
+		return value, Role(roleName), nil
+	default:
+		return "", "", fmt.Errorf("unknown role %q", roleName)
+	}
+}
+
+// openTenant stands in when no tokens or tenants are configured: the
+// daemon is open (local use) and every request is treated as operator.
+var openTenant = &Tenant{Name: "open", Role: RoleOperator}
+
+// authorize checks the request's bearer token and returns its tenant.
+func (s *Server) authorize(r *http.Request) (*Tenant, bool) {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	if len(s.Tenants) == 0 {
+		return openTenant, true
+	}
+	header := r.Header.Get("Authorization")
+	bearer, found := strings.CutPrefix(header, "Bearer ")
+	if !found {
+		return nil, false
+	}
+	for token, tenant := range s.Tenants {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(bearer)) == 1 {
+			return tenant, true
+		}
+	}
+	return nil, false
+}
+
+// tenantKey carries the authorized tenant in the request context.
+type tenantKey struct{}
+
+// tenantFrom returns the tenant requireRole stored for this request.
+func tenantFrom(r *http.Request) *Tenant {
+	if tenant, ok := r.Context().Value(tenantKey{}).(*Tenant); ok {
+		return tenant
+	}
+	return openTenant
+}
+
+// requireRole wraps a handler with token auth. Viewer tokens can reach
+// handlers gated on RoleViewer; operator endpoints need RoleOperator.
+// Every authorized request lands in its tenant's history.
+func (s *Server) requireRole(role Role, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenant, ok := s.authorize(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if role == RoleOperator && tenant.Role != RoleOperator {
+			http.Error(w, "operator token required", http.StatusForbidden)
+			return
+		}
+		tenant.record(r)
+		next(w, r.WithContext(context.WithValue(r.Context(), tenantKey{}, tenant)))
+	}
+}
+
+// requireJob additionally takes one of the tenant's job slots for the
+// duration of the request, enforcing the concurrency quota.
+func (s *Server) requireJob(role Role, next http.HandlerFunc) http.HandlerFunc {
+	return s.requireRole(role, func(w http.ResponseWriter, r *http.Request) {
+		tenant := tenantFrom(r)
+		if !tenant.acquireJob() {
+			http.Error(w, "tenant job quota exhausted, retry later", http.StatusTooManyRequests)
+			return
+		}
+		defer tenant.releaseJob()
+		next(w, r)
+	})
+}
@@ -0,0 +1,145 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the daemon's file-based configuration, the declarative
+// alternative to the serve flags for farm controllers:
+//
+//	roots:
+//	  - /mnt/plots
+//	tokens:
+//	  - "s3cret:viewer"
+//	tenants:
+//	  - "teamA:t0k3n:operator:/mnt/plots/a:2"
+//
+// Token and tenant entries use the same syntax as the --token and
+// --tenant flags.
+type Config struct {
+	Roots   []string `yaml:"roots"`
+	Tokens  []string `yaml:"tokens"`
+	Tenants []string `yaml:"tenants"`
+}
+
+// LoadConfig reads and fully validates a config file; nothing of a bad
+// file is applied.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := new(Config)
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if _, _, err := config.build(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+// build turns the config's string entries into the server's runtime
+// maps, validating every entry.
+func (c *Config) build() (map[string]Role, map[string]*Tenant, error) {
+	tokens := make(map[string]Role, len(c.Tokens))
+	for _, s := range c.Tokens {
+		token, role, err := ParseToken(s)
+		if err != nil {
+			return nil, nil, err
+		}
+		tokens[token] = role
+	}
+	tenants := make(map[string]*Tenant, len(c.Tenants))
+	for _, s := range c.Tenants {
+		token, tenant, err := ParseTenant(s)
+		if err != nil {
+			return nil, nil, err
+		}
+		tenants[token] = tenant
+	}
+	return tokens, tenants, nil
+}
+
+// configMu guards the Server fields a reload swaps; request handlers
+// read them through snapshot helpers.
+var configMu sync.RWMutex
+
+// Reload applies a validated config to the running server. Existing
+// tenants keep their history and job slots when their definition is
+// unchanged; new or altered tenants start fresh.
+func (s *Server) Reload(config *Config) error {
+	tokens, tenants, err := config.build()
+	if err != nil {
+		return err
+	}
+	for token, role := range tokens {
+		if _, exists := tenants[token]; !exists {
+			tenants[token] = &Tenant{Name: "default", Role: role}
+		}
+	}
+
+	configMu.Lock()
+	defer configMu.Unlock()
+	for token, tenant := range tenants {
+		if previous, exists := s.Tenants[token]; exists &&
+			previous.Name == tenant.Name && previous.Role == tenant.Role &&
+			previous.MaxJobs == tenant.MaxJobs && fmt.Sprint(previous.Roots) == fmt.Sprint(tenant.Roots) {
+			tenants[token] = previous
+			continue
+		}
+		tenant.init()
+	}
+	s.Roots = config.Roots
+	s.Tokens = tokens
+	s.Tenants = tenants
+	return nil
+}
+
+// watchConfig applies the config file on every change until ctx ends.
+// A file that fails to load or validate is ignored with a notice — the
+// previous config stays in force, which is the rollback a controller
+// pushing bad YAML needs.
+func (s *Server) watchConfig(ctx context.Context, path string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	// Watch the file itself and its name: editors and config
+	// controllers usually replace the file, which retires the old
+	// inode's watch.
+	if err := watcher.Add(path); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-watcher.Events:
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			watcher.Add(path) // re-arm after replace-by-rename
+			config, err := LoadConfig(path)
+			if err != nil {
+				fmt.Printf("config not applied, keeping previous: %v\n", err)
+				continue
+			}
+			if err := s.Reload(config); err != nil {
+				fmt.Printf("config not applied, keeping previous: %v\n", err)
+				continue
+			}
+			fmt.Printf("config reloaded from %s\n", path)
+		case err := <-watcher.Errors:
+			fmt.Printf("config watch: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Package daemon implements smtool's serve mode: a long-running HTTP
+// server exposing farm state. All endpoints in this file are strictly
+// read-only.
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/leveleven/smtool/internal/plots"
+	"github.com/leveleven/smtool/internal/workers"
+)
+
+// Server serves read-only farm state over HTTP.
+type Server struct {
+	Addr  string
+	Roots []string
+	// Tokens maps API tokens to their role. With no tokens configured
+	// the daemon is open, which is only sensible on localhost.
+	Tokens map[string]Role
+	// Tenants maps API tokens to full tenant definitions with their own
+	// roots, quotas and histories. Tokens and Tenants may be mixed; a
+	// plain token becomes a tenant confined to the server-wide roots.
+	Tenants map[string]*Tenant
+	// ConfigPath, when set, is watched for changes; edits to the file
+	// are validated and applied without a restart.
+	ConfigPath string
+}
+
+// Run serves until ctx is cancelled.
+func (s *Server) Run(ctx context.Context) error {
+	if s.Tenants == nil {
+		s.Tenants = make(map[string]*Tenant)
+	}
+	for token, role := range s.Tokens {
+		if _, exists := s.Tenants[token]; !exists {
+			s.Tenants[token] = &Tenant{Name: "default", Role: role}
+		}
+	}
+	for _, tenant := range s.Tenants {
+		tenant.init()
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.requireRole(RoleViewer, s.handleIndex))
+	mux.HandleFunc("/api/plots", s.requireRole(RoleViewer, s.handlePlots))
+	mux.HandleFunc("/api/history", s.requireRole(RoleViewer, s.handleHistory))
+	mux.HandleFunc("/api/transfer/list", s.requireJob(RoleOperator, s.handleTransferList))
+	mux.HandleFunc("/api/transfer/read", s.requireJob(RoleOperator, s.handleTransferRead))
+	mux.HandleFunc("/api/transfer/digests", s.requireJob(RoleOperator, s.handleTransferDigests))
+
+	server := &http.Server{Addr: s.Addr, Handler: mux}
+	group, ctx := workers.New(ctx)
+	group.Go("listen", func(context.Context) error {
+		fmt.Printf("serving on http://%s/\n", s.Addr)
+		if err := server.ListenAndServe(); err != http.ErrServerClosed {
+			return err
+		}
+		return nil
+	})
+	group.Go("enforce policies", s.enforcePolicies)
+	if s.ConfigPath != "" {
+		group.Go("watch config", func(ctx context.Context) error {
+			return s.watchConfig(ctx, s.ConfigPath)
+		})
+	}
+	group.Go("shutdown", func(ctx context.Context) error {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	})
+	return group.Wait()
+}
+
+// rootsFor returns the roots a tenant may see: its own when it has any,
+// the server-wide roots otherwise.
+func (s *Server) rootsFor(tenant *Tenant) []string {
+	if len(tenant.Roots) > 0 {
+		return tenant.Roots
+	}
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return s.Roots
+}
+
+// scanFor gathers the current inventory across a tenant's roots.
+func (s *Server) scanFor(tenant *Tenant) []plots.Plot {
+	var all []plots.Plot
+	for _, root := range s.rootsFor(tenant) {
+		found, err := plots.Scan(root)
+		if err != nil {
+			continue
+		}
+		all = append(all, found...)
+	}
+	return all
+}
+
+// handlePlots returns the decoded inventory as JSON.
+func (s *Server) handlePlots(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "read-only endpoint", http.StatusMethodNotAllowed)
+		return
+	}
+	tenant := tenantFrom(r)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.withPolicy(s.scanFor(tenant), s.rootsFor(tenant)))
+}
+
+// handleHistory returns the calling tenant's own request history; no
+// tenant can read another's.
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tenantFrom(r).recent())
+}
+
+// indexTemplate is a minimal page so a phone browser is enough to check
+// farm state.
+var indexTemplate = template.Must(template.New("index").Parse(`<!doctype html>
+<title>smtool</title>
+<h1>plots</h1>
+<table border="1" cellpadding="4">
+<tr><th>dir</th><th>units</th><th>label files</th><th>size</th><th>nonce</th></tr>
+{{range .}}<tr>
+<td>{{.Dir}}</td>
+<td>{{.Metadata.NumUnits}}</td>
+<td>{{.LabelFiles}}</td>
+<td>{{.SizeOnDisk}}</td>
+<td>{{if .HasNonce}}yes{{else}}missing{{end}}</td>
+</tr>{{end}}
+</table>`))
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	indexTemplate.Execute(w, s.scanFor(tenantFrom(r)))
+}
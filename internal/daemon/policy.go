@@ -0,0 +1,116 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/leveleven/smtool/internal/audit"
+	"github.com/leveleven/smtool/internal/plots"
+	"github.com/leveleven/smtool/internal/policy"
+	"github.com/leveleven/smtool/internal/scheduler"
+)
+
+// policyInterval is how often the daemon re-evaluates maintenance
+// policies. The policies themselves work in days; an hourly check is
+// plenty.
+const policyInterval = time.Hour
+
+// policyVerifySamples is the spotcheck size used when the daemon runs
+// an overdue verify task itself.
+const policyVerifySamples = 64
+
+// plotWithPolicy decorates a scanned plot with its maintenance
+// compliance for the inventory endpoints.
+type plotWithPolicy struct {
+	plots.Plot
+	Policy []policy.TaskStatus `json:"policy,omitempty"`
+}
+
+// withPolicy attaches each plot's policy status; plots under roots
+// without a policy file pass through unchanged.
+func (s *Server) withPolicy(found []plots.Plot, roots []string) []plotWithPolicy {
+	policies := make(map[string]*policy.Policy, len(roots))
+	for _, root := range roots {
+		if p, err := policy.Load(root); err == nil && p != nil {
+			policies[root] = p
+		}
+	}
+	decorated := make([]plotWithPolicy, 0, len(found))
+	for _, plot := range found {
+		entry := plotWithPolicy{Plot: plot}
+		for root, p := range policies {
+			if !within(plot.Dir, root) {
+				continue
+			}
+			if statuses, err := p.Evaluate(plot.Dir); err == nil {
+				entry.Policy = statuses
+			}
+			break
+		}
+		decorated = append(decorated, entry)
+	}
+	return decorated
+}
+
+// within reports whether dir lies under root.
+func within(dir, root string) bool {
+	return dir == root || len(dir) > len(root) && dir[:len(root)] == root && dir[len(root)] == '/'
+}
+
+// enforcePolicies periodically evaluates every root's policy and runs
+// the overdue tasks the daemon can perform itself (verify). Tasks it
+// cannot run unattended are announced so an operator sees them on the
+// console and in the audit trail.
+func (s *Server) enforcePolicies(ctx context.Context) error {
+	ticker := time.NewTicker(policyInterval)
+	defer ticker.Stop()
+	for {
+		s.runDuePolicies(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) runDuePolicies(ctx context.Context) {
+	configMu.RLock()
+	roots := append([]string(nil), s.Roots...)
+	configMu.RUnlock()
+
+	for _, root := range roots {
+		p, err := policy.Load(root)
+		if err != nil || p == nil {
+			continue
+		}
+		found, err := plots.Scan(root)
+		if err != nil {
+			continue
+		}
+		for _, plot := range found {
+			statuses, err := p.Evaluate(plot.Dir)
+			if err != nil {
+				continue
+			}
+			for _, status := range statuses {
+				if !status.Due {
+					continue
+				}
+				switch status.Task {
+				case "verify":
+					summary, err := scheduler.Run(ctx, []plots.Plot{plot}, policyVerifySamples)
+					audit.Record("spotcheck", plot.Dir, map[string]string{
+						"samples":    fmt.Sprint(summary.Checked),
+						"mismatches": fmt.Sprint(summary.Mismatches),
+						"policy":     "verify",
+					}, err)
+				default:
+					fmt.Printf("policy: %s is due for %q on %s; run it with smtool\n",
+						status.Task, root, plot.Dir)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package daemon
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tenant is one customer of a multi-tenant daemon: a token bound to its
+// own plot roots, a concurrency quota for heavy jobs and an isolated
+// request history. A tenant can never see another tenant's roots or
+// history.
+type Tenant struct {
+	Name string
+	Role Role
+	// Roots restricts the tenant to these plot roots; empty means the
+	// server-wide roots (used for legacy --token entries).
+	Roots []string
+	// MaxJobs caps the tenant's concurrent transfer/compute requests;
+	// zero means unlimited.
+	MaxJobs int
+
+	jobs    chan struct{}
+	mu      sync.Mutex
+	history []HistoryEntry
+}
+
+// HistoryEntry records one request a tenant made.
+type HistoryEntry struct {
+	Time   time.Time `json:"time"`
+	Method string    `json:"method"`
+	Path   string    `json:"path"`
+}
+
+// historyLimit bounds the per-tenant history ring.
+const historyLimit = 256
+
+// ParseTenant parses a "name:token:role:root[,root][:maxjobs]" flag
+// value.
+func ParseTenant(s string) (token string, tenant *Tenant, err error) {
+	parts := strings.SplitN(s, ":", 5)
+	if len(parts) < 4 || parts[0] == "" || parts[1] == "" {
+		return "", nil, fmt.Errorf("invalid tenant %q, want \"<name>:<token>:<role>:<root>[,<root>][:<maxjobs>]\"", s)
+	}
+	role := Role(parts[2])
+	if role != RoleViewer && role != RoleOperator {
+		return "", nil, fmt.Errorf("unknown role %q in tenant %q", parts[2], parts[0])
+	}
+	maxJobs := 0
+	if len(parts) == 5 {
+		maxJobs, err = strconv.Atoi(parts[4])
+		if err != nil || maxJobs < 0 {
+			return "", nil, fmt.Errorf("invalid maxjobs %q in tenant %q", parts[4], parts[0])
+		}
+	}
+	return parts[1], &Tenant{
+		Name:    parts[0],
+		Role:    role,
+		Roots:   strings.Split(parts[3], ","),
+		MaxJobs: maxJobs,
+	}, nil
+}
+
+// init prepares the tenant's quota channel.
+func (t *Tenant) init() {
+	if t.MaxJobs > 0 && t.jobs == nil {
+		t.jobs = make(chan struct{}, t.MaxJobs)
+	}
+}
+
+// acquireJob takes a job slot, reporting false when the quota is full.
+func (t *Tenant) acquireJob() bool {
+	if t.jobs == nil {
+		return true
+	}
+	select {
+	case t.jobs <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (t *Tenant) releaseJob() {
+	if t.jobs != nil {
+		<-t.jobs
+	}
+}
+
+// record appends a request to the tenant's history ring.
+func (t *Tenant) record(r *http.Request) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.history = append(t.history, HistoryEntry{Time: time.Now().UTC(), Method: r.Method, Path: r.URL.Path})
+	if len(t.history) > historyLimit {
+		t.history = t.history[len(t.history)-historyLimit:]
+	}
+}
+
+// recent returns a copy of the tenant's history, newest last.
+func (t *Tenant) recent() []HistoryEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]HistoryEntry(nil), t.history...)
+}
@@ -0,0 +1,149 @@
+package daemon
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/leveleven/smtool/internal/pathsafe"
+)
+
+// transferFile describes one file offered for transfer.
+type transferFile struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// isKeyMaterial reports whether a file name holds identity key
+// material, which no transfer endpoint may expose.
+func isKeyMaterial(name string) bool {
+	return name == "key.bin" || strings.HasSuffix(name, ".key")
+}
+
+// allowedPath canonicalizes a requested path through the pathsafe layer
+// and confines it to the roots the requesting tenant may see, resolving
+// symlinks on both sides so planted links cannot escape a root. Key
+// material is rejected here so the read and digest endpoints cannot
+// serve what the listing refuses to show.
+func (s *Server) allowedPath(r *http.Request, requested string) (string, error) {
+	path, err := pathsafe.Confine(s.rootsFor(tenantFrom(r)), requested)
+	if err != nil {
+		return "", err
+	}
+	if isKeyMaterial(filepath.Base(path)) {
+		return "", fmt.Errorf("key material is not transferable")
+	}
+	return path, nil
+}
+
+// handleTransferList returns the transferable files in a plot
+// directory. Key material is never offered.
+func (s *Server) handleTransferList(w http.ResponseWriter, r *http.Request) {
+	dir, err := s.allowedPath(r, r.URL.Query().Get("dir"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	var files []transferFile
+	for _, entry := range entries {
+		if entry.IsDir() || isKeyMaterial(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, transferFile{Name: entry.Name(), Size: info.Size()})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(files)
+}
+
+// handleTransferDigests returns the SHA-256 of every chunk of a file,
+// letting clients sync only missing or mismatching ranges.
+func (s *Server) handleTransferDigests(w http.ResponseWriter, r *http.Request) {
+	path, err := s.allowedPath(r, r.URL.Query().Get("path"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	chunkSize, err := strconv.ParseInt(r.URL.Query().Get("chunk"), 10, 64)
+	if err != nil || chunkSize <= 0 || chunkSize > 64<<20 {
+		http.Error(w, "bad chunk size", http.StatusBadRequest)
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	defer file.Close()
+
+	var digests []string
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := io.ReadFull(file, buf)
+		if n > 0 {
+			sum := sha256.Sum256(buf[:n])
+			digests = append(digests, hex.EncodeToString(sum[:]))
+		}
+		if err != nil {
+			break
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(digests)
+}
+
+// handleTransferRead serves one chunk of a file with its digest in the
+// X-Chunk-Sha256 header for inline verification.
+func (s *Server) handleTransferRead(w http.ResponseWriter, r *http.Request) {
+	path, err := s.allowedPath(r, r.URL.Query().Get("path"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	offset, err := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		http.Error(w, "bad offset", http.StatusBadRequest)
+		return
+	}
+	length, err := strconv.ParseInt(r.URL.Query().Get("length"), 10, 64)
+	if err != nil || length <= 0 || length > 32<<20 {
+		http.Error(w, "bad length (max 32MiB)", http.StatusBadRequest)
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	defer file.Close()
+
+	chunk := make([]byte, length)
+	n, err := file.ReadAt(chunk, offset)
+	if err != nil && n == 0 {
+		http.Error(w, err.Error(), http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+	chunk = chunk[:n]
+
+	sum := sha256.Sum256(chunk)
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("X-Chunk-Sha256", hex.EncodeToString(sum[:]))
+	w.Write(chunk)
+}
@@ -0,0 +1,65 @@
+// Package datadir discovers default spacemesh data directories so
+// commands can work without an explicit --path on typical setups.
+package datadir
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// Candidates returns existing directories where spacemesh node or post
+// data is conventionally stored on this OS, most likely first.
+func Candidates() []string {
+	var roots []string
+	home, err := os.UserHomeDir()
+	if err == nil {
+		switch runtime.GOOS {
+		case "darwin":
+			roots = append(roots,
+				filepath.Join(home, "Library", "Application Support", "Spacemesh"),
+			)
+		case "windows":
+			if appData := os.Getenv("APPDATA"); appData != "" {
+				roots = append(roots, filepath.Join(appData, "Spacemesh"))
+			}
+			if localAppData := os.Getenv("LOCALAPPDATA"); localAppData != "" {
+				roots = append(roots, filepath.Join(localAppData, "Spacemesh"))
+			}
+		default:
+			roots = append(roots,
+				filepath.Join(home, ".spacemesh"),
+				filepath.Join(home, ".config", "spacemesh"),
+				filepath.Join(home, ".local", "share", "spacemesh"),
+			)
+		}
+		roots = append(roots, filepath.Join(home, "post"))
+	}
+	if runtime.GOOS == "linux" {
+		// System installs and common docker volume mounts.
+		roots = append(roots,
+			"/var/lib/spacemesh",
+			"/data/spacemesh",
+		)
+		if volumes, err := filepath.Glob("/var/lib/docker/volumes/*/_data/spacemesh"); err == nil {
+			roots = append(roots, volumes...)
+		}
+	}
+
+	var existing []string
+	for _, root := range roots {
+		if info, err := os.Stat(root); err == nil && info.IsDir() {
+			existing = append(existing, root)
+			// A post/ subdirectory is the more precise hit.
+			if sub := filepath.Join(root, "post"); isDir(sub) {
+				existing = append(existing, sub)
+			}
+		}
+	}
+	return existing
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,89 @@
+// Package envinfo collects the machine profile that support threads
+// always end up asking for: OS and kernel, libpost version, CPU and GPU
+// models, and the mount options under the data. Collecting it once,
+// automatically, cuts the back-and-forth to one message.
+package envinfo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/leveleven/smtool/internal/cpufeat"
+	"github.com/leveleven/smtool/postrs"
+)
+
+// Profile renders the machine profile block. dir, when non-empty, adds
+// the mount options of the filesystem holding it. Every probe is best
+// effort: a missing source becomes a line noting so, never an error.
+func Profile(dir string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- environment ---\n")
+	fmt.Fprintf(&b, "os:      %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "kernel:  %s\n", kernelVersion())
+
+	if version, err := postrs.Available(""); err == nil {
+		fmt.Fprintf(&b, "libpost: %s\n", version)
+	} else {
+		fmt.Fprintf(&b, "libpost: unavailable (%v)\n", err)
+	}
+
+	cpu := cpufeat.Detect()
+	if cpu.Known {
+		fmt.Fprintf(&b, "cpu:     %s (%s)\n", cpu.Model, cpu)
+	} else {
+		fmt.Fprintf(&b, "cpu:     unknown\n")
+	}
+	if providers, err := postrs.Providers(""); err == nil {
+		for _, provider := range providers {
+			fmt.Fprintf(&b, "%s:     %s (provider %d)\n",
+				strings.ToLower(provider.Class.String()), provider.Model, provider.ID)
+		}
+	}
+
+	if dir != "" {
+		if options := mountOptions(dir); options != "" {
+			fmt.Fprintf(&b, "mount:   %s\n", options)
+		}
+	}
+	return b.String()
+}
+
+// kernelVersion reads the running kernel release.
+func kernelVersion() string {
+	release, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	if err != nil {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(release))
+}
+
+// mountOptions finds the longest mount point prefixing dir in
+// /proc/mounts and returns its filesystem type and options.
+func mountOptions(dir string) string {
+	mounts, err := os.ReadFile("/proc/mounts")
+	if err != nil {
+		return ""
+	}
+	resolved, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	best := ""
+	for _, line := range strings.Split(string(mounts), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		point := fields[1]
+		if !strings.HasPrefix(resolved+"/", strings.TrimSuffix(point, "/")+"/") {
+			continue
+		}
+		if best == "" || len(point) > len(strings.Fields(best)[1]) {
+			best = fmt.Sprintf("%s %s (%s) %s", fields[0], point, fields[2], fields[3])
+		}
+	}
+	return best
+}
@@ -0,0 +1,143 @@
+// Package export writes decoded record collections to analysis-friendly
+// formats. Commands that decode many records accept an --out flag and
+// hand their rows here; the file extension picks the writer, so the
+// data lands in pandas or DuckDB with typed columns instead of being
+// scraped from console output.
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Kind is the column type carried through to the output format.
+type Kind int
+
+const (
+	String Kind = iota
+	Int
+	Float
+	Bool
+	Timestamp
+)
+
+// Column names and types one output column.
+type Column struct {
+	Name string
+	Kind Kind
+}
+
+// Table is an ordered collection of typed rows.
+type Table struct {
+	Columns []Column
+	Rows    [][]any
+}
+
+// Append adds one row; values must match the column order.
+func (t *Table) Append(values ...any) {
+	t.Rows = append(t.Rows, values)
+}
+
+// Write stores the table at path; the extension selects the format.
+func Write(path string, t *Table) error {
+	switch filepath.Ext(path) {
+	case ".csv":
+		return writeCSV(path, t)
+	case ".parquet":
+		return writeParquet(path, t)
+	default:
+		return fmt.Errorf("unsupported export format %q, want .csv or .parquet", filepath.Ext(path))
+	}
+}
+
+func writeCSV(path string, t *Table) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	writer := csv.NewWriter(file)
+
+	header := make([]string, len(t.Columns))
+	for i, column := range t.Columns {
+		header[i] = column.Name
+	}
+	if err := writer.Write(header); err != nil {
+		file.Close()
+		return err
+	}
+	record := make([]string, len(t.Columns))
+	for _, row := range t.Rows {
+		for i, value := range row {
+			if ts, ok := value.(time.Time); ok {
+				record[i] = ts.Format(time.RFC3339)
+			} else {
+				record[i] = fmt.Sprint(value)
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func writeParquet(path string, t *Table) error {
+	group := parquet.Group{}
+	for _, column := range t.Columns {
+		var node parquet.Node
+		switch column.Kind {
+		case Int:
+			node = parquet.Int(64)
+		case Float:
+			node = parquet.Leaf(parquet.DoubleType)
+		case Bool:
+			node = parquet.Leaf(parquet.BooleanType)
+		case Timestamp:
+			node = parquet.Timestamp(parquet.Millisecond)
+		default:
+			node = parquet.String()
+		}
+		group[column.Name] = parquet.Optional(node)
+	}
+	schema := parquet.NewSchema("smtool", group)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	writer := parquet.NewGenericWriter[map[string]any](file, schema)
+	rows := make([]map[string]any, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		record := make(map[string]any, len(t.Columns))
+		for i, column := range t.Columns {
+			value := row[i]
+			if column.Kind == Timestamp {
+				if ts, ok := value.(time.Time); ok {
+					value = ts.UnixMilli()
+				}
+			}
+			record[column.Name] = value
+		}
+		rows = append(rows, record)
+	}
+	if _, err := writer.Write(rows); err != nil {
+		file.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,169 @@
+// Package fingerprint keeps a per-host registry of which compute
+// providers have been validated against a known-answer test. A GPU (or
+// driver update) that once produced invalid labels on this host stays
+// flagged, so plotting commands can refuse it before writing terabytes
+// of garbage.
+package fingerprint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/leveleven/smtool/postrs"
+)
+
+// Status is the recorded outcome of the last known-answer test for one
+// provider fingerprint.
+type Status struct {
+	Fingerprint string    `json:"fingerprint"`
+	Valid       bool      `json:"valid"`
+	Detail      string    `json:"detail,omitempty"`
+	CheckedAt   time.Time `json:"checkedAt"`
+}
+
+// registryPath mirrors the audit log's location convention.
+func registryPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".smtool", "provider-fingerprints.json"), nil
+}
+
+// Key builds the registry key for a provider: the device model plus the
+// libpost version, because a driver or library update can change a
+// provider's behavior as much as new hardware can.
+func Key(model, libpostVersion string) string {
+	return model + " / " + libpostVersion
+}
+
+func load() (map[string]Status, error) {
+	path, err := registryPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]Status{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	registry := map[string]Status{}
+	return registry, json.Unmarshal(data, &registry)
+}
+
+func save(registry map[string]Status) error {
+	path, err := registryPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(registry, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// Lookup returns the recorded status for a fingerprint, or nil when the
+// provider has never been tested on this host.
+func Lookup(key string) (*Status, error) {
+	registry, err := load()
+	if err != nil {
+		return nil, err
+	}
+	status, found := registry[key]
+	if !found {
+		return nil, nil
+	}
+	return &status, nil
+}
+
+// List returns every recorded status, ordered by fingerprint.
+func List() ([]Status, error) {
+	registry, err := load()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(registry))
+	for key := range registry {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	statuses := make([]Status, 0, len(keys))
+	for _, key := range keys {
+		statuses = append(statuses, registry[key])
+	}
+	return statuses, nil
+}
+
+// Record persists a test outcome for a fingerprint.
+func Record(key string, valid bool, detail string) error {
+	registry, err := load()
+	if err != nil {
+		return err
+	}
+	registry[key] = Status{
+		Fingerprint: key,
+		Valid:       valid,
+		Detail:      detail,
+		CheckedAt:   time.Now(),
+	}
+	return save(registry)
+}
+
+// katPositions is the number of labels the known-answer test computes.
+// Small enough to finish in seconds on a CPU, large enough that a
+// provider emitting zeros or garbage cannot pass by luck.
+const katPositions = 4096
+
+// KnownAnswerTest computes a batch of labels on the candidate provider
+// and on the CPU implementation with the same fixed commitment, and
+// records whether they agree. The CPU implementation is the reference:
+// it is pure scrypt with no device-specific fast paths.
+func KnownAnswerTest(providerID int, key string) (*Status, error) {
+	commitment := bytes.Repeat([]byte{0x5a}, 32)
+
+	reference, err := computeLabels(postrs.CPUProviderID, commitment)
+	if err != nil {
+		return nil, fmt.Errorf("reference computation: %w", err)
+	}
+	candidate, err := computeLabels(providerID, commitment)
+
+	valid := err == nil && bytes.Equal(reference, candidate)
+	detail := ""
+	switch {
+	case err != nil:
+		detail = err.Error()
+	case !valid:
+		detail = fmt.Sprintf("output differs from CPU reference over %d labels", katPositions)
+	}
+	if recordErr := Record(key, valid, detail); recordErr != nil {
+		return nil, recordErr
+	}
+	return Lookup(key)
+}
+
+func computeLabels(providerID int, commitment []byte) ([]byte, error) {
+	wo, err := postrs.NewOracle(
+		postrs.WithProviderID(providerID),
+		postrs.WithCommitment(commitment),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer wo.Close()
+	result, err := wo.Positions(0, katPositions-1)
+	if err != nil {
+		return nil, err
+	}
+	return result.Output, nil
+}
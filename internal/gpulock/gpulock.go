@@ -0,0 +1,55 @@
+// Package gpulock serializes GPU use across smtool processes (and
+// anything else honoring the same convention) with per-device advisory
+// file locks, so two jobs never run concurrent kernels on one card.
+package gpulock
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// dir returns the directory holding lock files, preferring the user
+// runtime dir so locks disappear on reboot.
+func dir() string {
+	if runtime := os.Getenv("XDG_RUNTIME_DIR"); runtime != "" {
+		return filepath.Join(runtime, "smtool")
+	}
+	return filepath.Join(os.TempDir(), "smtool-locks")
+}
+
+// Acquire takes the advisory lock for a device key (UUID or provider
+// id). With wait set the call queues behind the current holder;
+// otherwise it fails immediately when the device is busy. The returned
+// release function must be called when the job is done with the device.
+func Acquire(deviceKey string, wait bool) (release func(), err error) {
+	if err := os.MkdirAll(dir(), 0o755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir(), fmt.Sprintf("gpu-%s.lock", deviceKey))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	how := unix.LOCK_EX
+	if !wait {
+		how |= unix.LOCK_NB
+	}
+	if err := unix.Flock(int(file.Fd()), how); err != nil {
+		file.Close()
+		if err == unix.EWOULDBLOCK {
+			return nil, fmt.Errorf("device %s is in use by another job (use --wait-gpu to queue)", deviceKey)
+		}
+		return nil, fmt.Errorf("lock device %s: %w", deviceKey, err)
+	}
+	// Record the holder for debugging; content is informational only.
+	fmt.Fprintf(file, "pid %d\n", os.Getpid())
+
+	return func() {
+		unix.Flock(int(file.Fd()), unix.LOCK_UN)
+		file.Close()
+	}, nil
+}
@@ -0,0 +1,91 @@
+// Package metrics exposes job progress in the Prometheus text format,
+// so farm monitoring can track a long-running genonce, init or verify
+// without parsing its console output. The exposition format is simple
+// enough that the handful of counters here does not justify a client
+// library dependency.
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Counter is a monotonically increasing metric.
+type Counter struct {
+	name, help string
+	value      atomic.Uint64
+}
+
+// Add increments the counter.
+func (c *Counter) Add(n uint64) { c.value.Add(n) }
+
+// LabeledGauge is a gauge with one label dimension, e.g. per provider.
+type LabeledGauge struct {
+	name, help, label string
+
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// Set records the gauge value for one label value.
+func (g *LabeledGauge) Set(labelValue string, value float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.values == nil {
+		g.values = make(map[string]float64)
+	}
+	g.values[labelValue] = value
+}
+
+// The process-wide metrics, registered in exposition order.
+var (
+	LabelsScanned = &Counter{
+		name: "smtool_labels_scanned_total",
+		help: "Labels scanned or computed by jobs in this process.",
+	}
+	Errors = &Counter{
+		name: "smtool_errors_total",
+		help: "Errors hit by jobs in this process.",
+	}
+	ProviderRate = &LabeledGauge{
+		name:  "smtool_provider_labels_per_second",
+		help:  "Recent label throughput per compute provider.",
+		label: "provider",
+	}
+)
+
+// Serve starts the metrics endpoint on addr, serving /metrics.
+func Serve(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", handle)
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			fmt.Printf("metrics server: %v\n", err)
+		}
+	}()
+	fmt.Printf("metrics on http://%s/metrics\n", addr)
+}
+
+func handle(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	for _, c := range []*Counter{LabelsScanned, Errors} {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n",
+			c.name, c.help, c.name, c.name, c.value.Load())
+	}
+	for _, g := range []*LabeledGauge{ProviderRate} {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", g.name, g.help, g.name)
+		g.mu.Lock()
+		keys := make([]string, 0, len(g.values))
+		for key := range g.values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(w, "%s{%s=%q} %g\n", g.name, g.label, key, g.values[key])
+		}
+		g.mu.Unlock()
+	}
+}
@@ -0,0 +1,183 @@
+// Package netstats fetches historical network-wide statistics (total
+// committed space, active smeshers) from a Spacemesh explorer API, with
+// a local cache so repeated capacity-planning runs do not hammer the
+// explorer. Closed epochs never change, so cached entries are kept
+// forever; only the newest epoch is refetched.
+package netstats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// EpochStats holds the network totals for one epoch.
+type EpochStats struct {
+	Epoch uint32 `json:"epoch"`
+	// TotalSpace is the network's committed space in bytes.
+	TotalSpace uint64 `json:"totalSpace"`
+	// Smeshers is the number of active smeshers.
+	Smeshers uint64 `json:"smeshers"`
+}
+
+// cachePath mirrors the audit log's location convention.
+func cachePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".smtool", "netstats-cache.json"), nil
+}
+
+func loadCache() map[uint32]EpochStats {
+	cache := make(map[uint32]EpochStats)
+	path, err := cachePath()
+	if err != nil {
+		return cache
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	var entries []EpochStats
+	if json.Unmarshal(data, &entries) == nil {
+		for _, entry := range entries {
+			cache[entry.Epoch] = entry
+		}
+	}
+	return cache
+}
+
+func saveCache(cache map[uint32]EpochStats) {
+	path, err := cachePath()
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return
+	}
+	entries := make([]EpochStats, 0, len(cache))
+	for _, entry := range cache {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Epoch < entries[j].Epoch })
+	if data, err := json.MarshalIndent(entries, "", "  "); err == nil {
+		os.WriteFile(path, data, 0o644)
+	}
+}
+
+// explorerEpoch is the subset of the explorer's epoch document smtool
+// reads. Different explorer versions nest the numbers differently, so
+// both layouts are tried.
+type explorerEpoch struct {
+	Number uint32 `json:"number"`
+	Stats  struct {
+		Current struct {
+			Security uint64 `json:"security"`
+			Smeshers uint64 `json:"smeshers"`
+		} `json:"current"`
+	} `json:"stats"`
+	Security uint64 `json:"security"`
+	Smeshers uint64 `json:"smeshers"`
+}
+
+func (e explorerEpoch) toStats() EpochStats {
+	stats := EpochStats{Epoch: e.Number, TotalSpace: e.Security, Smeshers: e.Smeshers}
+	if e.Stats.Current.Security > 0 {
+		stats.TotalSpace = e.Stats.Current.Security
+	}
+	if e.Stats.Current.Smeshers > 0 {
+		stats.Smeshers = e.Stats.Current.Smeshers
+	}
+	return stats
+}
+
+// Fetch returns the last epochs worth of stats from the explorer at
+// source, serving closed epochs from the cache where possible.
+func Fetch(ctx context.Context, source string, epochs int) ([]EpochStats, error) {
+	cache := loadCache()
+
+	url := fmt.Sprintf("%s/epochs?pagesize=%d", strings.TrimRight(source, "/"), epochs)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// Offline: whatever the cache holds is better than nothing.
+		if len(cache) > 0 {
+			fmt.Printf("explorer unreachable (%v), using cached stats\n", err)
+			return lastCached(cache, epochs), nil
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("explorer returned %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	var document struct {
+		Data []explorerEpoch `json:"data"`
+	}
+	if err := json.Unmarshal(body, &document); err != nil {
+		return nil, fmt.Errorf("parse explorer response: %w", err)
+	}
+	if len(document.Data) == 0 {
+		return nil, fmt.Errorf("explorer returned no epochs")
+	}
+
+	for _, epoch := range document.Data {
+		cache[epoch.Number] = epoch.toStats()
+	}
+	saveCache(cache)
+	return lastCached(cache, epochs), nil
+}
+
+// lastCached returns the newest n epochs from the cache, oldest first.
+func lastCached(cache map[uint32]EpochStats, n int) []EpochStats {
+	entries := make([]EpochStats, 0, len(cache))
+	for _, entry := range cache {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Epoch < entries[j].Epoch })
+	if len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+	return entries
+}
+
+// Sparkline renders values as a compact terminal sparkline.
+func Sparkline(values []uint64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	levels := []rune("▁▂▃▄▅▆▇█")
+	min, max := values[0], values[0]
+	for _, v := range values {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	var sb strings.Builder
+	for _, v := range values {
+		index := 0
+		if max > min {
+			index = int(uint64(len(levels)-1) * (v - min) / (max - min))
+		}
+		sb.WriteRune(levels[index])
+	}
+	return sb.String()
+}
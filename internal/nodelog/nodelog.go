@@ -0,0 +1,65 @@
+// Package nodelog contains tolerant helpers for reading go-spacemesh
+// log files, which appear in the wild in both zap console and JSON
+// encodings.
+package nodelog
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Line is one parsed log line. Time is zero when no timestamp could be
+// recognized.
+type Line struct {
+	Time time.Time
+	Text string
+}
+
+var consoleTimestamp = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:?\d{2})?)`)
+
+// Parse extracts the timestamp and message from one raw log line.
+func Parse(raw string) Line {
+	raw = strings.TrimSpace(raw)
+	if strings.HasPrefix(raw, "{") {
+		var entry struct {
+			T   string `json:"T"`
+			TS  string `json:"ts"`
+			Msg string `json:"M"`
+			Mg  string `json:"msg"`
+		}
+		if err := json.Unmarshal([]byte(raw), &entry); err == nil {
+			line := Line{Text: entry.Msg}
+			if line.Text == "" {
+				line.Text = entry.Mg
+			}
+			for _, candidate := range []string{entry.T, entry.TS} {
+				if t, err := parseTime(candidate); err == nil {
+					line.Time = t
+					break
+				}
+			}
+			if line.Text != "" {
+				return line
+			}
+		}
+	}
+	line := Line{Text: raw}
+	if m := consoleTimestamp.FindString(raw); m != "" {
+		if t, err := parseTime(m); err == nil {
+			line.Time = t
+		}
+	}
+	return line
+}
+
+func parseTime(s string) (time.Time, error) {
+	s = strings.Replace(s, " ", "T", 1)
+	for _, layout := range []string{time.RFC3339Nano, time.RFC3339, "2006-01-02T15:04:05.000-0700", "2006-01-02T15:04:05"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, &time.ParseError{Value: s}
+}
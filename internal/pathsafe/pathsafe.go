@@ -0,0 +1,57 @@
+// Package pathsafe is the vetted path layer for anything that takes a
+// path from the outside world. It canonicalizes paths (absolute,
+// symlinks resolved) and confines them to declared roots, so a crafted
+// request or a symlink planted inside a served root can never reach
+// files outside it. Serve mode routes every requested path through
+// Confine; local commands use Canonical for consistent symlink
+// handling.
+package pathsafe
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Canonical returns the absolute, symlink-free form of path. The final
+// element may not exist yet (output files); in that case its parent is
+// resolved and the base name re-joined.
+func Canonical(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err == nil {
+		return resolved, nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+	parent, err := filepath.EvalSymlinks(filepath.Dir(abs))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(parent, filepath.Base(abs)), nil
+}
+
+// Confine canonicalizes requested and checks the result lies under one
+// of the roots. Both sides are resolved, so neither ".." components nor
+// symlinks pointing out of a root can escape it.
+func Confine(roots []string, requested string) (string, error) {
+	resolved, err := Canonical(requested)
+	if err != nil {
+		return "", fmt.Errorf("resolve %s: %w", requested, err)
+	}
+	for _, root := range roots {
+		rootResolved, err := Canonical(root)
+		if err != nil {
+			continue
+		}
+		if resolved == rootResolved || strings.HasPrefix(resolved, rootResolved+string(os.PathSeparator)) {
+			return resolved, nil
+		}
+	}
+	return "", fmt.Errorf("%s is outside the served roots", requested)
+}
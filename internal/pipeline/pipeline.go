@@ -0,0 +1,117 @@
+// Package pipeline runs a declared sequence of smtool operations, so
+// common maintenance flows (scan → verify → genonce → notify) don't
+// need external shell scripting and fragile output parsing.
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Step is one operation in a plan. Args are regular smtool CLI
+// arguments; the step is executed as a child smtool process so its exit
+// status and output are captured cleanly.
+type Step struct {
+	Name string   `yaml:"name"`
+	Args []string `yaml:"args"`
+	// When gates the step on a previous result: "<step>.ok",
+	// "<step>.failed", or empty to always run.
+	When string `yaml:"when,omitempty"`
+	// ContinueOnError keeps the plan going when this step fails.
+	ContinueOnError bool `yaml:"continueOnError,omitempty"`
+}
+
+// Plan is a parsed plan.yaml.
+type Plan struct {
+	Steps []Step `yaml:"steps"`
+}
+
+// Load reads and validates a plan file.
+func Load(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	plan := new(Plan)
+	if err := yaml.Unmarshal(data, plan); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	names := make(map[string]bool, len(plan.Steps))
+	for i, step := range plan.Steps {
+		if step.Name == "" {
+			return nil, fmt.Errorf("step %d: missing name", i+1)
+		}
+		if names[step.Name] {
+			return nil, fmt.Errorf("step %d: duplicate name %q", i+1, step.Name)
+		}
+		if len(step.Args) == 0 {
+			return nil, fmt.Errorf("step %q: missing args", step.Name)
+		}
+		if _, _, err := parseCondition(step.When, names); err != nil {
+			return nil, fmt.Errorf("step %q: %w", step.Name, err)
+		}
+		names[step.Name] = true
+	}
+	return plan, nil
+}
+
+// parseCondition splits "<step>.ok" / "<step>.failed" and checks the
+// referenced step was declared earlier in the plan.
+func parseCondition(when string, declared map[string]bool) (step string, wantOK bool, err error) {
+	if when == "" {
+		return "", false, nil
+	}
+	name, outcome, found := strings.Cut(when, ".")
+	if !found || (outcome != "ok" && outcome != "failed") {
+		return "", false, fmt.Errorf("invalid condition %q, want \"<step>.ok\" or \"<step>.failed\"", when)
+	}
+	if declared != nil && !declared[name] {
+		return "", false, fmt.Errorf("condition %q references unknown or later step", when)
+	}
+	return name, outcome == "ok", nil
+}
+
+// Run executes the plan and returns an error if any non-tolerated step
+// failed. Steps started after ctx expires are not run; a running step
+// is killed.
+func (p *Plan) Run(ctx context.Context) error {
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	succeeded := make(map[string]bool, len(p.Steps))
+	var failed []string
+	for _, step := range p.Steps {
+		if name, wantOK, _ := parseCondition(step.When, nil); name != "" {
+			if succeeded[name] != wantOK {
+				fmt.Printf("== %s: skipped (%s not met)\n", step.Name, step.When)
+				continue
+			}
+		}
+
+		fmt.Printf("== %s: smtool %s\n", step.Name, strings.Join(step.Args, " "))
+		cmd := exec.CommandContext(ctx, self, step.Args...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		err := cmd.Run()
+		succeeded[step.Name] = err == nil
+		if err != nil {
+			fmt.Printf("== %s: failed: %v\n", step.Name, err)
+			if !step.ContinueOnError {
+				failed = append(failed, step.Name)
+				break
+			}
+			failed = append(failed, step.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("plan failed at: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
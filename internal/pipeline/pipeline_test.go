@@ -0,0 +1,33 @@
+package pipeline
+
+import "testing"
+
+func TestParseCondition(t *testing.T) {
+	declared := map[string]bool{"init": true}
+
+	step, wantOK, err := parseCondition("", declared)
+	if err != nil || step != "" {
+		t.Errorf("parseCondition(\"\") = %q, %v, %v, want no condition", step, wantOK, err)
+	}
+
+	step, wantOK, err = parseCondition("init.ok", declared)
+	if err != nil || step != "init" || !wantOK {
+		t.Errorf("parseCondition(init.ok) = %q, %v, %v", step, wantOK, err)
+	}
+
+	step, wantOK, err = parseCondition("init.failed", declared)
+	if err != nil || step != "init" || wantOK {
+		t.Errorf("parseCondition(init.failed) = %q, %v, %v", step, wantOK, err)
+	}
+
+	// nil declared skips the declaration check, the Run-time mode.
+	if step, _, err := parseCondition("later.ok", nil); err != nil || step != "later" {
+		t.Errorf("parseCondition with nil declared = %q, %v", step, err)
+	}
+
+	for _, in := range []string{"init", "init.maybe", "ok", "missing.ok"} {
+		if _, _, err := parseCondition(in, declared); err == nil {
+			t.Errorf("parseCondition(%q) succeeded, want error", in)
+		}
+	}
+}
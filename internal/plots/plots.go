@@ -0,0 +1,79 @@
+// Package plots discovers and summarizes POST plot directories under a
+// root, shared by the scan-style commands and serve mode.
+package plots
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spacemeshos/post/shared"
+)
+
+// MetadataFileName marks a directory as a plot.
+const MetadataFileName = "postdata_metadata.json"
+
+// Plot summarizes one discovered plot directory.
+type Plot struct {
+	Dir        string               `json:"dir"`
+	Metadata   *shared.PostMetadata `json:"metadata"`
+	SizeOnDisk int64                `json:"sizeOnDisk"`
+	LabelFiles int                  `json:"labelFiles"`
+	HasNonce   bool                 `json:"hasNonce"`
+}
+
+// Scan walks root and returns a summary for every directory containing
+// a postdata_metadata.json. Unreadable metadata is skipped rather than
+// failing the whole scan.
+func Scan(root string) ([]Plot, error) {
+	var found []Plot
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // skip unreadable subtrees
+		}
+		if info.IsDir() || info.Name() != MetadataFileName {
+			return nil
+		}
+		plot, err := describe(filepath.Dir(path))
+		if err != nil {
+			return nil
+		}
+		found = append(found, *plot)
+		return nil
+	})
+	return found, err
+}
+
+// describe summarizes a single plot directory.
+func describe(dir string) (*Plot, error) {
+	data, err := os.ReadFile(filepath.Join(dir, MetadataFileName))
+	if err != nil {
+		return nil, err
+	}
+	metadata := new(shared.PostMetadata)
+	if err := json.Unmarshal(data, metadata); err != nil {
+		return nil, err
+	}
+
+	plot := &Plot{
+		Dir:      dir,
+		Metadata: metadata,
+		HasNonce: metadata.Nonce != nil,
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		plot.SizeOnDisk += info.Size()
+		if strings.HasPrefix(entry.Name(), "postdata_") && strings.HasSuffix(entry.Name(), ".bin") {
+			plot.LabelFiles++
+		}
+	}
+	return plot, nil
+}
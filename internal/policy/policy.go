@@ -0,0 +1,106 @@
+// Package policy attaches maintenance schedules to plot roots. A
+// smtool-policy.yaml in a root declares how often its plots must be
+// verified, CRC-scanned or rehearsed; compliance is judged against the
+// audit log, where every such operation already leaves a record.
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/leveleven/smtool/internal/audit"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName marks a root as having a maintenance policy.
+const FileName = "smtool-policy.yaml"
+
+// Policy declares the maintenance cadence for every plot under a root:
+//
+//	verifyEvery: 168h     # spotcheck labels weekly
+//	crcScanEvery: 720h    # CRC-scan state files monthly
+//	rehearseEvery: 336h   # rehearse proving each epoch
+//
+// A zero (or omitted) interval disables that task.
+type Policy struct {
+	VerifyEvery   time.Duration `yaml:"verifyEvery"`
+	CRCScanEvery  time.Duration `yaml:"crcScanEvery"`
+	RehearseEvery time.Duration `yaml:"rehearseEvery"`
+}
+
+// TaskStatus is one task's compliance state for one plot.
+type TaskStatus struct {
+	Task    string        `json:"task"`
+	Every   time.Duration `json:"every"`
+	LastRun time.Time     `json:"lastRun,omitempty"`
+	Due     bool          `json:"due"`
+}
+
+// Load reads the policy file of a root; a root without one returns
+// (nil, nil).
+func Load(root string) (*Policy, error) {
+	data, err := os.ReadFile(filepath.Join(root, FileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	policy := new(Policy)
+	return policy, yaml.Unmarshal(data, policy)
+}
+
+// taskOps maps each policy task to the audit log operations that count
+// as performing it.
+var taskOps = map[string][]string{
+	"verify":   {"spotcheck", "verifyData", "farm move verify"},
+	"crcscan":  {"crcscan"},
+	"rehearse": {"rehearse", "prove"},
+}
+
+// Evaluate judges one plot directory against the policy using the
+// audit log. Only successful runs count.
+func (p *Policy) Evaluate(dir string) ([]TaskStatus, error) {
+	entries, err := audit.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	lastRun := func(ops []string) time.Time {
+		var last time.Time
+		for _, entry := range entries {
+			if entry.Result != "ok" || entry.Target != dir {
+				continue
+			}
+			for _, op := range ops {
+				if strings.EqualFold(entry.Op, op) && entry.Time.After(last) {
+					last = entry.Time
+				}
+			}
+		}
+		return last
+	}
+
+	var statuses []TaskStatus
+	for _, task := range []struct {
+		name  string
+		every time.Duration
+	}{
+		{"verify", p.VerifyEvery},
+		{"crcscan", p.CRCScanEvery},
+		{"rehearse", p.RehearseEvery},
+	} {
+		if task.every <= 0 {
+			continue
+		}
+		last := lastRun(taskOps[task.name])
+		statuses = append(statuses, TaskStatus{
+			Task:    task.name,
+			Every:   task.every,
+			LastRun: last,
+			Due:     last.IsZero() || time.Since(last) > task.every,
+		})
+	}
+	return statuses, nil
+}
@@ -0,0 +1,61 @@
+// Package postformat describes the on-disk POST data format so label
+// offset math lives in one place. Today only format version 1 (16-byte
+// AES labels) is deployed, but keying every size and encoding decision
+// on a descriptor means a future format lands as one new registry entry
+// instead of a rewrite of offset arithmetic across the tool.
+package postformat
+
+import (
+	"fmt"
+
+	"github.com/spacemeshos/post/shared"
+)
+
+// Format describes one POST data format version.
+type Format struct {
+	// Version is the format version number.
+	Version uint32
+	// LabelSize is the size in bytes of a single label.
+	LabelSize uint64
+	// Encoding names the label derivation scheme, for display only.
+	Encoding string
+}
+
+// formats is the registry of known format versions.
+var formats = map[uint32]Format{
+	1: {Version: 1, LabelSize: 16, Encoding: "aes"},
+}
+
+// Current is the format produced by deployed initializers.
+var Current = formats[1]
+
+// ForVersion looks up a format descriptor by version number.
+func ForVersion(version uint32) (Format, error) {
+	format, ok := formats[version]
+	if !ok {
+		return Format{}, fmt.Errorf("unknown post format version %d (known: 1); this smtool is too old for the plot", version)
+	}
+	return format, nil
+}
+
+// ForMetadata returns the format a plot's metadata was written with.
+// Deployed metadata carries no version marker yet, so everything maps
+// to version 1; once a version field exists this is where it gets read.
+func ForMetadata(metadata *shared.PostMetadata) Format {
+	return Current
+}
+
+// Offset returns the byte offset of the label at position within a
+// buffer starting at position base.
+func (f Format) Offset(base, position uint64) uint64 {
+	return (position - base) * f.LabelSize
+}
+
+// Label extracts a copy of the label at position from a buffer that
+// starts at position base.
+func (f Format) Label(buf []byte, base, position uint64) []byte {
+	offset := f.Offset(base, position)
+	label := make([]byte, f.LabelSize)
+	copy(label, buf[offset:offset+f.LabelSize])
+	return label
+}
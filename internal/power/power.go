@@ -0,0 +1,59 @@
+// Package power reports whether the host is running on battery or UPS
+// power, so long jobs can checkpoint and pause instead of hammering a
+// disk that may lose power mid-write. Detection uses the kernel's
+// /sys/class/power_supply tree; hosts without any mains-class supply
+// entry (typical servers) are treated as on mains.
+package power
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// supplyRoot is a variable for tests and exotic setups.
+var supplyRoot = "/sys/class/power_supply"
+
+// OnBattery reports whether every mains-class power supply is offline.
+func OnBattery() bool {
+	entries, err := os.ReadDir(supplyRoot)
+	if err != nil {
+		return false
+	}
+	sawMains := false
+	for _, entry := range entries {
+		kind, err := os.ReadFile(filepath.Join(supplyRoot, entry.Name(), "type"))
+		if err != nil {
+			continue
+		}
+		switch strings.TrimSpace(string(kind)) {
+		case "Mains", "UPS", "USB":
+		default:
+			continue
+		}
+		sawMains = true
+		online, err := os.ReadFile(filepath.Join(supplyRoot, entry.Name(), "online"))
+		if err == nil && strings.TrimSpace(string(online)) == "1" {
+			return false
+		}
+	}
+	return sawMains
+}
+
+// WaitForMains blocks until mains power returns or ctx expires,
+// polling the supply tree. It returns immediately when already on
+// mains.
+func WaitForMains(ctx context.Context) error {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+	for OnBattery() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+// Package provenance keeps a tamper-evident sidecar next to plot data
+// recording which tool, host and provider produced or modified it.
+// Records form a hash chain: each one commits to its predecessor, so an
+// audit can tell whether the history was truncated or edited.
+package provenance
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/leveleven/smtool/internal/cpufeat"
+)
+
+// FileName is the sidecar file kept in the plot directory.
+const FileName = "provenance.json"
+
+// Record is one link in a plot's provenance chain.
+type Record struct {
+	Tool     string    `json:"tool"`
+	Version  string    `json:"version"`
+	Op       string    `json:"op"`
+	Provider string    `json:"provider"`
+	Host     string    `json:"host"`
+	HostID   string    `json:"hostId"`
+	Time     time.Time `json:"time"`
+	Prev     string    `json:"prev,omitempty"`
+	Hash     string    `json:"hash"`
+}
+
+// digest hashes the record's canonical form, excluding the Hash field
+// itself.
+func (r Record) digest() string {
+	r.Hash = ""
+	data, _ := json.Marshal(r)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hostFingerprint identifies the machine without recording anything a
+// support thread could not ask for anyway: a hash over hostname, kernel
+// and CPU model.
+func hostFingerprint() (host, id string) {
+	host, _ = os.Hostname()
+	kernel, _ := os.ReadFile("/proc/sys/kernel/osrelease")
+	sum := sha256.Sum256([]byte(host + "\x00" + string(kernel) + "\x00" + cpufeat.Detect().Model))
+	return host, hex.EncodeToString(sum[:8])
+}
+
+// Append adds a record for an operation on dir, chaining it to the
+// current tail.
+func Append(dir, version, op, provider string) error {
+	records, err := Read(dir)
+	if err != nil {
+		return err
+	}
+	host, id := hostFingerprint()
+	record := Record{
+		Tool:     "smtool",
+		Version:  version,
+		Op:       op,
+		Provider: provider,
+		Host:     host,
+		HostID:   id,
+		Time:     time.Now().UTC(),
+	}
+	if len(records) > 0 {
+		record.Prev = records[len(records)-1].Hash
+	}
+	record.Hash = record.digest()
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(filepath.Join(dir, FileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = fmt.Fprintln(file, string(line))
+	return err
+}
+
+// Read returns the chain, oldest first. A missing sidecar is an empty
+// chain, not an error.
+func Read(dir string) ([]Record, error) {
+	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var records []Record
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for decoder.More() {
+		var record Record
+		if err := decoder.Decode(&record); err != nil {
+			return records, fmt.Errorf("%s: torn record after %d entries", FileName, len(records))
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// Verify walks the chain and reports the first broken link: a record
+// whose hash does not match its content, or whose Prev does not match
+// its predecessor.
+func Verify(records []Record) error {
+	prev := ""
+	for i, record := range records {
+		if record.Hash != record.digest() {
+			return fmt.Errorf("record %d: content does not match its hash", i)
+		}
+		if record.Prev != prev {
+			return fmt.Errorf("record %d: chain broken (expected prev %q)", i, prev)
+		}
+		prev = record.Hash
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package provenance
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	for _, op := range []string{"init", "genonce"} {
+		if err := Append(dir, "test", op, "0"); err != nil {
+			t.Fatalf("Append(%s): %v", op, err)
+		}
+	}
+	records, err := Read(dir)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Read returned %d records, want 2", len(records))
+	}
+	if err := Verify(records); err != nil {
+		t.Errorf("Verify(intact chain): %v", err)
+	}
+
+	// Editing a record's content must break its hash.
+	tampered := append([]Record(nil), records...)
+	tampered[0].Op = "resize"
+	if err := Verify(tampered); err == nil || !strings.Contains(err.Error(), "record 0") {
+		t.Errorf("Verify(edited record) = %v, want record 0 hash mismatch", err)
+	}
+
+	// Dropping the first record must break the successor's Prev link.
+	if err := Verify(records[1:]); err == nil || !strings.Contains(err.Error(), "chain broken") {
+		t.Errorf("Verify(truncated chain) = %v, want broken chain", err)
+	}
+}
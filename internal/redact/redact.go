@@ -0,0 +1,60 @@
+// Package redact masks identifying values (node IDs, coinbase
+// addresses, IP addresses) in command output so diagnostics can be
+// shared publicly. Every distinct value is replaced by a pseudonym that
+// stays stable for the lifetime of the process, so output remains
+// correlatable without being identifying.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var (
+	// 32-byte hex strings: node IDs, commitment ATX IDs and friends.
+	hexIDPattern = regexp.MustCompile(`\b[0-9a-fA-F]{64}\b`)
+	// Bech32 spacemesh coinbase addresses.
+	coinbasePattern = regexp.MustCompile(`\bs[mt]1[02-9ac-hj-np-z]{8,}\b`)
+	// IPv4 addresses, optionally with a port.
+	ipPattern = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}(?::\d{1,5})?\b`)
+)
+
+// Redactor rewrites sensitive values to stable per-run pseudonyms.
+type Redactor struct {
+	pseudonyms map[string]string
+	counters   map[string]int
+}
+
+// New returns an empty Redactor.
+func New() *Redactor {
+	return &Redactor{
+		pseudonyms: make(map[string]string),
+		counters:   make(map[string]int),
+	}
+}
+
+// pseudonym returns the stable replacement for value within a class
+// (e.g. "node", "coinbase", "ip"), allocating a new one on first use.
+func (r *Redactor) pseudonym(class, value string) string {
+	if p, ok := r.pseudonyms[value]; ok {
+		return p
+	}
+	r.counters[class]++
+	p := fmt.Sprintf("<%s-%d>", class, r.counters[class])
+	r.pseudonyms[value] = p
+	return p
+}
+
+// Redact replaces all recognized sensitive values in s.
+func (r *Redactor) Redact(s string) string {
+	s = hexIDPattern.ReplaceAllStringFunc(s, func(m string) string {
+		return r.pseudonym("id", m)
+	})
+	s = coinbasePattern.ReplaceAllStringFunc(s, func(m string) string {
+		return r.pseudonym("coinbase", m)
+	})
+	s = ipPattern.ReplaceAllStringFunc(s, func(m string) string {
+		return r.pseudonym("ip", m)
+	})
+	return s
+}
@@ -0,0 +1,39 @@
+package redact
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactor(t *testing.T) {
+	r := New()
+	nodeA := strings.Repeat("ab", 32)
+	nodeB := strings.Repeat("cd", 32)
+
+	got := r.Redact("node " + nodeA + " peers with " + nodeB)
+	if strings.Contains(got, nodeA) || strings.Contains(got, nodeB) {
+		t.Fatalf("IDs survived redaction: %q", got)
+	}
+	if got != "node <id-1> peers with <id-2>" {
+		t.Errorf("Redact = %q", got)
+	}
+
+	// The same value keeps its pseudonym across calls, so output stays
+	// correlatable.
+	if again := r.Redact(nodeA); again != "<id-1>" {
+		t.Errorf("repeated value = %q, want <id-1>", again)
+	}
+
+	if got := r.Redact("coinbase sm1qqqqqqqqxyz23"); got != "coinbase <coinbase-1>" {
+		t.Errorf("coinbase = %q", got)
+	}
+	if got := r.Redact("dial 192.168.1.10:9092"); got != "dial <ip-1>" {
+		t.Errorf("ip = %q", got)
+	}
+
+	// Short hex (a 20-byte address, say) is not a node ID and stays.
+	short := strings.Repeat("ef", 20)
+	if got := r.Redact(short); got != short {
+		t.Errorf("short hex was redacted: %q", got)
+	}
+}
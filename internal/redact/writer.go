@@ -0,0 +1,39 @@
+package redact
+
+import (
+	"bufio"
+	"io"
+	"os"
+)
+
+// Stdout replaces the process stdout with a pipe that passes every line
+// through the redactor before writing it to the original stdout. It
+// returns a function that must be called before exit to flush the pipe.
+//
+// Rewriting os.Stdout itself (rather than an io.Writer plumbed through
+// every command) guarantees the mask also covers output produced by
+// third-party code paths such as spew.
+func Stdout(r *Redactor) (flush func(), err error) {
+	real := os.Stdout
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	os.Stdout = pw
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scanner := bufio.NewScanner(pr)
+		scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+		for scanner.Scan() {
+			io.WriteString(real, r.Redact(scanner.Text())+"\n")
+		}
+	}()
+
+	return func() {
+		pw.Close()
+		<-done
+		os.Stdout = real
+	}, nil
+}
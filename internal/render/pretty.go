@@ -0,0 +1,118 @@
+package render
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Limits for the human-readable format, bound to the --max-depth and
+// --max-bytes flags. spew used to print multi-megabyte structs whole;
+// these keep a terminal usable and truncate explicitly instead of
+// flooding.
+var (
+	MaxDepth = 8
+	MaxBytes = 256
+	// Base64 switches byte-slice rendering from hex to base64.
+	Base64 bool
+)
+
+// pretty writes v in the bounded human-readable format: stable field
+// order, depth- and byte-limited, with explicit truncation markers.
+func pretty(b *strings.Builder, v reflect.Value, indent string, depth int) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			b.WriteString("nil")
+			return
+		}
+		v = v.Elem()
+	}
+	if depth > MaxDepth {
+		b.WriteString("... (max depth)")
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			writeBytes(b, v)
+			return
+		}
+		fmt.Fprintf(b, "%s (len %d) [", v.Type(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			fmt.Fprintf(b, "\n%s  ", indent)
+			pretty(b, v.Index(i), indent+"  ", depth+1)
+		}
+		if v.Len() > 0 {
+			fmt.Fprintf(b, "\n%s", indent)
+		}
+		b.WriteString("]")
+	case reflect.Map:
+		keys := make([]string, 0, v.Len())
+		byKey := make(map[string]reflect.Value, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			keys = append(keys, key)
+			byKey[key] = iter.Value()
+		}
+		sort.Strings(keys)
+		fmt.Fprintf(b, "%s {", v.Type())
+		for _, key := range keys {
+			fmt.Fprintf(b, "\n%s  %s: ", indent, key)
+			pretty(b, byKey[key], indent+"  ", depth+1)
+		}
+		if len(keys) > 0 {
+			fmt.Fprintf(b, "\n%s", indent)
+		}
+		b.WriteString("}")
+	case reflect.Struct:
+		fmt.Fprintf(b, "%s {", v.Type())
+		printed := false
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			printed = true
+			fmt.Fprintf(b, "\n%s  %s: ", indent, field.Name)
+			pretty(b, v.Field(i), indent+"  ", depth+1)
+		}
+		if printed {
+			fmt.Fprintf(b, "\n%s", indent)
+		}
+		b.WriteString("}")
+	case reflect.String:
+		fmt.Fprintf(b, "%q", v.String())
+	default:
+		if v.CanInterface() {
+			fmt.Fprintf(b, "%v", v.Interface())
+		} else {
+			b.WriteString("?")
+		}
+	}
+}
+
+// writeBytes renders a byte slice or array in hex (or base64), showing
+// at most MaxBytes bytes with an explicit truncation note.
+func writeBytes(b *strings.Builder, v reflect.Value) {
+	length := v.Len()
+	buf := make([]byte, length)
+	reflect.Copy(reflect.ValueOf(buf), v)
+
+	shown := buf
+	if MaxBytes > 0 && length > MaxBytes {
+		shown = buf[:MaxBytes]
+	}
+	if Base64 {
+		fmt.Fprintf(b, "%d bytes b64:%s", length, base64.StdEncoding.EncodeToString(shown))
+	} else {
+		fmt.Fprintf(b, "%d bytes %s", length, hex.EncodeToString(shown))
+	}
+	if len(shown) < length {
+		fmt.Fprintf(b, "... (%d more)", length-len(shown))
+	}
+}
@@ -0,0 +1,105 @@
+// Package render turns decoded chain structures into the output format
+// selected with the global --output flag. The spew format (named for
+// the library it replaced) is the bounded human-readable default; json
+// and yaml hex-encode byte fields so the output survives a jq or yq
+// pipeline.
+package render
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the selected output format; commands read it through Dump.
+// Set from the global --output flag.
+var Format = "spew"
+
+// Dump writes v to stdout in the selected format.
+func Dump(v any) error {
+	switch Format {
+	case "spew":
+		var b strings.Builder
+		pretty(&b, reflect.ValueOf(v), "", 0)
+		fmt.Println(b.String())
+		return nil
+	case "json":
+		encoded, err := json.MarshalIndent(hexify(reflect.ValueOf(v)), "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(encoded))
+		return nil
+	case "yaml":
+		encoded, err := yaml.Marshal(hexify(reflect.ValueOf(v)))
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(encoded))
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q (want json, yaml or spew)", Format)
+	}
+}
+
+// hexify converts v into plain maps/slices/scalars with every byte
+// slice or byte array replaced by its hex string, so the structure
+// marshals identically under json and yaml.
+func hexify(v reflect.Value) any {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			buf := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(buf), v)
+			return hex.EncodeToString(buf)
+		}
+		out := make([]any, v.Len())
+		for i := range out {
+			out[i] = hexify(v.Index(i))
+		}
+		return out
+	case reflect.Map:
+		out := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out[fmt.Sprint(iter.Key().Interface())] = hexify(iter.Value())
+		}
+		return out
+	case reflect.Struct:
+		out := make(map[string]any, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if field.Anonymous {
+				// Flatten embedded structs the way json does, so the
+				// output mirrors the wire structure rather than Go's
+				// embedding.
+				if embedded, ok := hexify(v.Field(i)).(map[string]any); ok {
+					for key, value := range embedded {
+						out[key] = value
+					}
+					continue
+				}
+			}
+			out[field.Name] = hexify(v.Field(i))
+		}
+		return out
+	default:
+		if !v.CanInterface() {
+			return nil
+		}
+		return v.Interface()
+	}
+}
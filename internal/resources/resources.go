@@ -0,0 +1,91 @@
+// Package resources is the process-wide resource manager: one place to
+// cap concurrent GPU jobs, disk-bound workers and bulk memory use,
+// configured once via global flags and respected by every subsystem.
+// It complements gpulock, which arbitrates between processes; this
+// package arbitrates within one.
+package resources
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Limits configured from the global flags; zero means unlimited.
+var (
+	MaxGPUJobs     int
+	MaxDiskWorkers int
+	// MaxMemory caps the bytes of bulk buffers held at once.
+	MaxMemory uint64
+)
+
+var (
+	once     sync.Once
+	gpuSlots chan struct{}
+	diskSlot chan struct{}
+
+	memMu   sync.Mutex
+	memCond = sync.NewCond(&memMu)
+	memUsed uint64
+)
+
+func setup() {
+	once.Do(func() {
+		if MaxGPUJobs > 0 {
+			gpuSlots = make(chan struct{}, MaxGPUJobs)
+		}
+		if MaxDiskWorkers > 0 {
+			diskSlot = make(chan struct{}, MaxDiskWorkers)
+		}
+	})
+}
+
+// acquire takes a slot from sem, or returns immediately when the limit
+// is unconfigured.
+func acquire(ctx context.Context, sem chan struct{}) (func(), error) {
+	if sem == nil {
+		return func() {}, nil
+	}
+	select {
+	case sem <- struct{}{}:
+		return func() { <-sem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// AcquireGPU blocks until a GPU job slot is free.
+func AcquireGPU(ctx context.Context) (func(), error) {
+	setup()
+	return acquire(ctx, gpuSlots)
+}
+
+// AcquireDisk blocks until a disk-worker slot is free.
+func AcquireDisk(ctx context.Context) (func(), error) {
+	setup()
+	return acquire(ctx, diskSlot)
+}
+
+// ReserveMemory blocks until n bytes fit inside the memory budget. A
+// single request larger than the whole budget fails rather than
+// deadlocks.
+func ReserveMemory(n uint64) (func(), error) {
+	if MaxMemory == 0 {
+		return func() {}, nil
+	}
+	if n > MaxMemory {
+		return nil, fmt.Errorf("%d bytes exceeds the --max-memory budget of %d", n, MaxMemory)
+	}
+	memMu.Lock()
+	for memUsed+n > MaxMemory {
+		memCond.Wait()
+	}
+	memUsed += n
+	memMu.Unlock()
+	return func() {
+		memMu.Lock()
+		memUsed -= n
+		memMu.Unlock()
+		memCond.Broadcast()
+	}, nil
+}
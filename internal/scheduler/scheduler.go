@@ -0,0 +1,266 @@
+// Package scheduler runs spotcheck verification across many plots on
+// one host. Per-plot reader goroutines pull sampled labels off their
+// disks while a single compute worker recomputes them on the provider,
+// so the GPU stays busy while the disks alternate instead of each plot
+// being verified serially. Provider initialization is deduplicated per
+// commitment, the expensive part of small verification jobs.
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/leveleven/smtool/internal/metrics"
+	"github.com/leveleven/smtool/internal/plots"
+	"github.com/leveleven/smtool/internal/postformat"
+	"github.com/leveleven/smtool/internal/resources"
+	"github.com/leveleven/smtool/internal/workers"
+	"github.com/leveleven/smtool/postrs"
+	"github.com/spacemeshos/post/shared"
+)
+
+// Sample is one spotcheck unit: a label read from disk awaiting
+// recomputation.
+type Sample struct {
+	Plot     string
+	Metadata *shared.PostMetadata
+	Position uint64
+	Want     []byte
+}
+
+// Summary aggregates a run's outcome.
+type Summary struct {
+	Checked    int
+	Mismatches int
+	Errors     []error
+}
+
+// Run spotchecks samplesPerPlot random labels in every plot.
+func Run(ctx context.Context, found []plots.Plot, samplesPerPlot int) (Summary, error) {
+	var summary Summary
+	if len(found) == 0 {
+		return summary, fmt.Errorf("no plots to verify")
+	}
+
+	// One channel per plot, filled by that plot's disk reader. Small
+	// buffers keep readers ahead of the compute worker without holding
+	// much label data in memory. Readers run in a workers.Group so a
+	// panic in one cancels the rest instead of hanging the drain loop.
+	group, ctx := workers.New(ctx)
+	channels := make([]chan Sample, len(found))
+	for i, plot := range found {
+		plot := plot
+		channels[i] = make(chan Sample, 8)
+		ch := channels[i]
+		group.Go("read "+plot.Dir, func(ctx context.Context) error {
+			return readSamples(ctx, plot, samplesPerPlot, ch)
+		})
+	}
+
+	// oracles dedupes provider initialization by commitment; plots
+	// belonging to the same identity share one initializer.
+	oracles := make(map[string]*postrs.WorkOracle)
+	lastPos := make(map[string]uint64)
+	defer func() {
+		for _, oracle := range oracles {
+			oracle.Close()
+		}
+	}()
+
+	// Round-robin over the plots until every reader is drained, so the
+	// provider alternates between disks instead of finishing one plot
+	// before touching the next.
+	open := len(channels)
+	for open > 0 {
+		for i, ch := range channels {
+			if ch == nil {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return summary, ctx.Err()
+			case sample, ok := <-ch:
+				if !ok {
+					channels[i] = nil
+					open--
+					continue
+				}
+				if err := check(sample, oracles, lastPos); err != nil {
+					if _, isMismatch := err.(mismatchError); isMismatch {
+						summary.Mismatches++
+					}
+					metrics.Errors.Add(1)
+					summary.Errors = append(summary.Errors, err)
+				}
+				summary.Checked++
+				metrics.LabelsScanned.Add(1)
+			}
+		}
+	}
+	if err := group.Wait(); err != nil {
+		return summary, err
+	}
+	return summary, nil
+}
+
+type mismatchError struct{ error }
+
+// check recomputes one sample's label on the provider and compares it
+// with the bytes read from disk.
+func check(sample Sample, oracles map[string]*postrs.WorkOracle, lastPos map[string]uint64) error {
+	commitment := postrs.CommitmentBytes(sample.Metadata.NodeId, sample.Metadata.CommitmentAtxId)
+	key := hex.EncodeToString(commitment)
+
+	oracle := oracles[key]
+	// The oracle only accepts ascending positions; interleaved readers
+	// can regress, in which case the initializer is recreated. Readers
+	// emit ascending positions per plot, so this is rare.
+	if oracle != nil && sample.Position <= lastPos[key] {
+		oracle.Close()
+		oracle = nil
+	}
+	if oracle == nil {
+		var err error
+		oracle, err = postrs.NewOracle(
+			postrs.WithProviderID(postrs.CPUProviderID),
+			postrs.WithCommitment(commitment),
+		)
+		if err != nil {
+			return fmt.Errorf("%s: %w", sample.Plot, err)
+		}
+		oracles[key] = oracle
+	}
+
+	result, err := oracle.Positions(sample.Position, sample.Position)
+	if err != nil {
+		return fmt.Errorf("%s position %d: %w", sample.Plot, sample.Position, err)
+	}
+	lastPos[key] = sample.Position
+	format := postformat.ForMetadata(sample.Metadata)
+	if !bytes.Equal(result.Output[:format.LabelSize], sample.Want) {
+		return mismatchError{fmt.Errorf("%s position %d: disk label %x, recomputed %x",
+			sample.Plot, sample.Position, sample.Want, result.Output[:format.LabelSize])}
+	}
+	return nil
+}
+
+// readSamples reads count random labels from the plot's label files in
+// ascending position order and sends them for verification.
+func readSamples(ctx context.Context, plot plots.Plot, count int, out chan<- Sample) error {
+	defer close(out)
+	releaseSlot, err := resources.AcquireDisk(ctx)
+	if err != nil {
+		return err
+	}
+	defer releaseSlot()
+	metadata := plot.Metadata
+	format := postformat.ForMetadata(metadata)
+	numLabels := uint64(metadata.NumUnits) * metadata.LabelsPerUnit
+	if numLabels == 0 {
+		return nil
+	}
+
+	positions := make([]uint64, count)
+	for i := range positions {
+		positions[i] = rand.Uint64() % numLabels
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i] < positions[j] })
+
+	labelsPerFile := metadata.MaxFileSize / format.LabelSize
+	if labelsPerFile == 0 {
+		return fmt.Errorf("%s: metadata reports MaxFileSize %d, smaller than one label", plot.Dir, metadata.MaxFileSize)
+	}
+	for _, position := range positions {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		file := filepath.Join(plot.Dir, fmt.Sprintf("postdata_%d.bin", position/labelsPerFile))
+		handle, err := os.Open(file)
+		if err != nil {
+			continue
+		}
+		label := make([]byte, format.LabelSize)
+		_, err = handle.ReadAt(label, int64((position%labelsPerFile)*format.LabelSize))
+		handle.Close()
+		if err != nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case out <- Sample{Plot: plot.Dir, Metadata: metadata, Position: position, Want: label}:
+		}
+	}
+	return nil
+}
+
+// VerifyRanges spotchecks freshly written label ranges, sampling the
+// given fraction of each range (at least one position per range). It is
+// the post-write hook used by repair-style commands to catch bad GPU
+// output before declaring success, instead of at first proving.
+func VerifyRanges(ctx context.Context, plot plots.Plot, ranges [][2]uint64, coverage float64) (Summary, error) {
+	var summary Summary
+	metadata := plot.Metadata
+	format := postformat.ForMetadata(metadata)
+	labelsPerFile := metadata.MaxFileSize / format.LabelSize
+	if labelsPerFile == 0 {
+		return summary, fmt.Errorf("%s: metadata reports MaxFileSize %d, smaller than one label", plot.Dir, metadata.MaxFileSize)
+	}
+
+	var positions []uint64
+	for _, r := range ranges {
+		if r[1] < r[0] {
+			continue
+		}
+		span := r[1] - r[0] + 1
+		count := uint64(float64(span) * coverage)
+		if count == 0 {
+			count = 1
+		}
+		for i := uint64(0); i < count; i++ {
+			positions = append(positions, r[0]+rand.Uint64()%span)
+		}
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i] < positions[j] })
+
+	oracles := make(map[string]*postrs.WorkOracle)
+	lastPos := make(map[string]uint64)
+	defer func() {
+		for _, oracle := range oracles {
+			oracle.Close()
+		}
+	}()
+	for _, position := range positions {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+		file := filepath.Join(plot.Dir, fmt.Sprintf("postdata_%d.bin", position/labelsPerFile))
+		handle, err := os.Open(file)
+		if err != nil {
+			summary.Errors = append(summary.Errors, err)
+			continue
+		}
+		label := make([]byte, format.LabelSize)
+		_, err = handle.ReadAt(label, int64((position%labelsPerFile)*format.LabelSize))
+		handle.Close()
+		if err != nil {
+			summary.Errors = append(summary.Errors, fmt.Errorf("%s position %d: %w", plot.Dir, position, err))
+			continue
+		}
+		sample := Sample{Plot: plot.Dir, Metadata: metadata, Position: position, Want: label}
+		if err := check(sample, oracles, lastPos); err != nil {
+			if _, isMismatch := err.(mismatchError); isMismatch {
+				summary.Mismatches++
+			}
+			summary.Errors = append(summary.Errors, err)
+		}
+		summary.Checked++
+	}
+	return summary, nil
+}
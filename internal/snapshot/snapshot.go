@@ -0,0 +1,118 @@
+// Package snapshot creates filesystem-level snapshots (ZFS, btrfs)
+// before mutating operations, giving transactional safety for
+// operations that rewrite large plots.
+package snapshot
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Prefix tags every snapshot smtool creates so rollback and cleanup
+// only ever touch our own snapshots.
+const Prefix = "smtool-"
+
+// mount describes the filesystem a path lives on.
+type mount struct {
+	point  string // mountpoint
+	device string // device, or dataset name for zfs
+	fstype string
+}
+
+// mountFor finds the longest-prefix mount for path in /proc/mounts.
+func mountFor(path string) (*mount, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open("/proc/mounts")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var best *mount
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		point := fields[1]
+		if abs != point && !strings.HasPrefix(abs, point+string(os.PathSeparator)) && point != "/" {
+			continue
+		}
+		if best == nil || len(point) > len(best.point) {
+			best = &mount{point: point, device: fields[0], fstype: fields[2]}
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no mount found for %s", abs)
+	}
+	return best, nil
+}
+
+// Supported reports whether path lives on a filesystem smtool can
+// snapshot.
+func Supported(path string) bool {
+	m, err := mountFor(path)
+	if err != nil {
+		return false
+	}
+	return m.fstype == "zfs" || m.fstype == "btrfs"
+}
+
+// Create snapshots the filesystem under path and returns an identifier
+// usable with Rollback.
+func Create(path string) (string, error) {
+	m, err := mountFor(path)
+	if err != nil {
+		return "", err
+	}
+	tag := Prefix + time.Now().Format("20060102-150405")
+	switch m.fstype {
+	case "zfs":
+		name := m.device + "@" + tag
+		if out, err := exec.Command("zfs", "snapshot", name).CombinedOutput(); err != nil {
+			return "", fmt.Errorf("zfs snapshot: %v: %s", err, out)
+		}
+		return name, nil
+	case "btrfs":
+		dir := filepath.Join(m.point, ".smtool-snapshots")
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return "", err
+		}
+		dest := filepath.Join(dir, tag)
+		if out, err := exec.Command("btrfs", "subvolume", "snapshot", "-r", m.point, dest).CombinedOutput(); err != nil {
+			return "", fmt.Errorf("btrfs snapshot: %v: %s", err, out)
+		}
+		return dest, nil
+	default:
+		return "", fmt.Errorf("%s is on %s, which smtool cannot snapshot", path, m.fstype)
+	}
+}
+
+// Rollback restores the state captured by a snapshot identifier
+// returned from Create.
+func Rollback(id string) error {
+	if strings.Contains(id, "@") {
+		if !strings.Contains(id, "@"+Prefix) {
+			return fmt.Errorf("refusing to roll back %s: not an smtool snapshot", id)
+		}
+		if out, err := exec.Command("zfs", "rollback", id).CombinedOutput(); err != nil {
+			return fmt.Errorf("zfs rollback: %v: %s", err, out)
+		}
+		return nil
+	}
+	if !strings.Contains(filepath.Base(id), Prefix) {
+		return fmt.Errorf("refusing to roll back %s: not an smtool snapshot", id)
+	}
+	// btrfs has no in-place rollback for a mounted subvolume; restoring
+	// a read-only snapshot safely needs a copy back.
+	return fmt.Errorf("btrfs snapshots cannot be rolled back in place; copy files back from %s", id)
+}
@@ -0,0 +1,51 @@
+// Package throttle paces bulk writes so plotting and repair can share
+// a disk with a running node's database without starving it.
+package throttle
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/time/rate"
+)
+
+// Writer wraps w so that writes are paced to the given bandwidth.
+type Writer struct {
+	w       io.Writer
+	limiter *rate.Limiter
+}
+
+// NewWriter returns a writer limited to mbps megabytes per second.
+// A non-positive mbps returns w unchanged.
+func NewWriter(w io.Writer, mbps float64) io.Writer {
+	if mbps <= 0 {
+		return w
+	}
+	bytesPerSecond := mbps * 1e6
+	return &Writer{
+		w: w,
+		// Allow bursts of up to a quarter second of budget so large
+		// buffered writes don't stall artificially.
+		limiter: rate.NewLimiter(rate.Limit(bytesPerSecond), int(bytesPerSecond/4)+1),
+	}
+}
+
+func (t *Writer) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p
+		if max := t.limiter.Burst(); len(chunk) > max {
+			chunk = chunk[:max]
+		}
+		if err := t.limiter.WaitN(context.Background(), len(chunk)); err != nil {
+			return written, err
+		}
+		n, err := t.w.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
@@ -0,0 +1,211 @@
+// Package transfer pulls plot files from a remote smtool agent with
+// resumable chunked reads, inline digest verification and bandwidth
+// caps — a replacement for rsync-over-ssh when rebalancing plots.
+package transfer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/leveleven/smtool/internal/resources"
+	"github.com/leveleven/smtool/internal/throttle"
+)
+
+// ChunkSize is the transfer unit; each chunk is digest-verified before
+// being appended, so a resumed transfer never keeps a torn chunk.
+const ChunkSize int64 = 16 << 20
+
+// Client pulls files from one remote agent.
+type Client struct {
+	BaseURL string // e.g. http://hostA:8555
+	Token   string
+	// MaxMBps caps download bandwidth; 0 means unlimited.
+	MaxMBps float64
+
+	// written accumulates the byte ranges actually fetched (as opposed
+	// to reused), so callers can verify just-written data afterwards.
+	written []Range
+}
+
+// Range is one byte range fetched into a local file during Pull.
+type Range struct {
+	Name   string
+	Offset int64
+	Length int64
+}
+
+// Written returns the ranges fetched by Pull calls so far. Chunks whose
+// local copy already matched the remote digest are not included.
+func (c *Client) Written() []Range {
+	return c.written
+}
+
+type remoteFile struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %s: %s", path, resp.Status, body)
+	}
+	return resp, nil
+}
+
+// Pull transfers every file of remoteDir into localDir, resuming
+// partial files from their current size.
+func (c *Client) Pull(ctx context.Context, remoteDir, localDir string) error {
+	resp, err := c.get(ctx, "/api/transfer/list", url.Values{"dir": {remoteDir}})
+	if err != nil {
+		return err
+	}
+	var files []remoteFile
+	err = json.NewDecoder(resp.Body).Decode(&files)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(localDir, 0o755); err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := c.pullFile(ctx, filepath.Join(remoteDir, file.Name), filepath.Join(localDir, file.Name), file.Size); err != nil {
+			return fmt.Errorf("%s: %w", file.Name, err)
+		}
+	}
+	return nil
+}
+
+// pullFile brings the local copy up to date, fetching only chunks that
+// are missing or whose digest differs from the remote — a stale or
+// partial destination costs only the mismatching ranges.
+func (c *Client) pullFile(ctx context.Context, remotePath, localPath string, size int64) error {
+	remoteDigests, err := c.chunkDigests(ctx, remotePath)
+	if err != nil {
+		return err
+	}
+
+	local, err := os.OpenFile(localPath, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	defer local.Close()
+	localSize := int64(0)
+	if info, err := local.Stat(); err == nil {
+		localSize = info.Size()
+	}
+
+	fetched, reused := 0, 0
+	buf := make([]byte, ChunkSize)
+	for index, want := range remoteDigests {
+		offset := int64(index) * ChunkSize
+		length := ChunkSize
+		if remaining := size - offset; remaining < length {
+			length = remaining
+		}
+
+		if offset+length <= localSize {
+			n, err := local.ReadAt(buf[:length], offset)
+			if err == nil && int64(n) == length {
+				sum := sha256.Sum256(buf[:length])
+				if hex.EncodeToString(sum[:]) == want {
+					reused++
+					continue
+				}
+			}
+		}
+		if err := c.fetchChunk(ctx, remotePath, local, offset, length, want); err != nil {
+			return err
+		}
+		c.written = append(c.written, Range{Name: filepath.Base(localPath), Offset: offset, Length: length})
+		fetched++
+		fmt.Printf("\r%s: %d fetched, %d reused of %d chunks", filepath.Base(localPath), fetched, reused, len(remoteDigests))
+	}
+	if fetched > 0 {
+		fmt.Println()
+	} else {
+		fmt.Printf("%s: up to date (%d chunks reused)\n", filepath.Base(localPath), reused)
+	}
+	if err := local.Truncate(size); err != nil {
+		return err
+	}
+	return local.Sync()
+}
+
+// chunkDigests fetches the remote per-chunk digest list.
+func (c *Client) chunkDigests(ctx context.Context, remotePath string) ([]string, error) {
+	resp, err := c.get(ctx, "/api/transfer/digests", url.Values{
+		"path":  {remotePath},
+		"chunk": {fmt.Sprint(ChunkSize)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var digests []string
+	return digests, json.NewDecoder(resp.Body).Decode(&digests)
+}
+
+// fetchChunk downloads one chunk, verifies it against the expected
+// digest and writes it at its offset.
+func (c *Client) fetchChunk(ctx context.Context, remotePath string, local *os.File, offset, length int64, want string) error {
+	releaseMemory, err := resources.ReserveMemory(uint64(length))
+	if err != nil {
+		return err
+	}
+	defer releaseMemory()
+	resp, err := c.get(ctx, "/api/transfer/read", url.Values{
+		"path":   {remotePath},
+		"offset": {fmt.Sprint(offset)},
+		"length": {fmt.Sprint(length)},
+	})
+	if err != nil {
+		return err
+	}
+	chunk, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(chunk)
+	if got := hex.EncodeToString(sum[:]); got != want || got != resp.Header.Get("X-Chunk-Sha256") {
+		return fmt.Errorf("chunk at offset %d failed digest verification", offset)
+	}
+	paced := throttle.NewWriter(&offsetWriter{file: local, offset: offset}, c.MaxMBps)
+	_, err = paced.Write(chunk)
+	return err
+}
+
+// offsetWriter writes sequentially starting at a fixed file offset.
+type offsetWriter struct {
+	file   *os.File
+	offset int64
+}
+
+func (w *offsetWriter) Write(p []byte) (int, error) {
+	n, err := w.file.WriteAt(p, w.offset)
+	w.offset += int64(n)
+	return n, err
+}
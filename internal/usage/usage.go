@@ -0,0 +1,93 @@
+// Package usage collects a resource consumption summary for a finished
+// job: wall time, CPU time, peak RSS and disk IO, so runs can be
+// compared without external profiling.
+package usage
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Summary describes the resources a job consumed.
+type Summary struct {
+	Wall       time.Duration
+	CPU        time.Duration
+	PeakRSS    uint64 // bytes
+	ReadBytes  uint64
+	WriteBytes uint64
+}
+
+// Collect gathers a Summary for the current process, with the wall
+// clock measured since start.
+func Collect(start time.Time) Summary {
+	s := Summary{Wall: time.Since(start)}
+
+	var ru unix.Rusage
+	if err := unix.Getrusage(unix.RUSAGE_SELF, &ru); err == nil {
+		s.CPU = time.Duration(ru.Utime.Nano() + ru.Stime.Nano())
+		// ru_maxrss is in kilobytes on Linux.
+		s.PeakRSS = uint64(ru.Maxrss) * 1024
+	}
+	s.ReadBytes, s.WriteBytes = procIO()
+	return s
+}
+
+// procIO reads cumulative disk IO from /proc/self/io; it returns zeros
+// on platforms or kernels without it.
+func procIO() (read, write uint64) {
+	data, err := os.ReadFile("/proc/self/io")
+	if err != nil {
+		return 0, 0
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, found := strings.Cut(line, ": ")
+		if !found {
+			continue
+		}
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "read_bytes":
+			read = n
+		case "write_bytes":
+			write = n
+		}
+	}
+	return read, write
+}
+
+// Print writes the summary to stdout. units describes the job's unit
+// of work (e.g. "labels") and done the amount completed, used for the
+// average throughput line; pass 0 to omit it.
+func (s Summary) Print(units string, done uint64) {
+	fmt.Println("--- resource usage ---")
+	fmt.Printf("wall time:   %v\n", s.Wall.Round(time.Millisecond))
+	fmt.Printf("cpu time:    %v (%.0f%% of wall)\n",
+		s.CPU.Round(time.Millisecond), 100*float64(s.CPU)/float64(s.Wall))
+	fmt.Printf("peak rss:    %s\n", formatBytes(s.PeakRSS))
+	fmt.Printf("disk read:   %s\n", formatBytes(s.ReadBytes))
+	fmt.Printf("disk write:  %s\n", formatBytes(s.WriteBytes))
+	if done > 0 && s.Wall > 0 {
+		fmt.Printf("throughput:  %.0f %s/s\n", float64(done)/s.Wall.Seconds(), units)
+	}
+}
+
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
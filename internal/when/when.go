@@ -0,0 +1,99 @@
+// Package when centralizes how smtool renders and parses times in
+// reports. Output uses a configurable timezone with both ISO and
+// relative forms; filters accept human-friendly ranges like "past 7d"
+// or "last 2 epochs" alongside plain durations and dates.
+package when
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Zone is the IANA timezone reports render in; empty means the system
+// local zone. Set by the root --tz flag.
+var Zone string
+
+// EpochDuration is the mainnet epoch length, used for "N epochs"
+// ranges.
+const EpochDuration = 14 * 24 * time.Hour
+
+func location() *time.Location {
+	if Zone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(Zone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// Format renders a time in the configured zone with a relative suffix,
+// e.g. "2024-03-02T14:05:00+01:00 (3h ago)".
+func Format(t time.Time) string {
+	return fmt.Sprintf("%s (%s)", t.In(location()).Format(time.RFC3339), Relative(t))
+}
+
+// Relative renders how far in the past (or future) a time lies.
+func Relative(t time.Time) string {
+	d := time.Since(t)
+	suffix := "ago"
+	if d < 0 {
+		d, suffix = -d, "from now"
+	}
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds %s", int(d.Seconds()), suffix)
+	case d < time.Hour:
+		return fmt.Sprintf("%dm %s", int(d.Minutes()), suffix)
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh %s", int(d.Hours()), suffix)
+	default:
+		return fmt.Sprintf("%dd %s", int(d.Hours()/24), suffix)
+	}
+}
+
+// ParseSince turns a human-friendly range into its cutoff time. It
+// accepts "past 7d" / "last 2 epochs" phrasings, bare forms like "7d",
+// "2 epochs" or "72h", and absolute RFC3339 or YYYY-MM-DD dates. An
+// empty string means no cutoff (the zero time).
+func ParseSince(s string) (time.Time, error) {
+	raw := strings.TrimSpace(s)
+	// Relative phrasings are matched case-insensitively; RFC3339 is
+	// parsed from the original spelling because its T and Z markers are
+	// case-sensitive.
+	s = strings.ToLower(raw)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, prefix := range []string{"past ", "last "} {
+		s = strings.TrimPrefix(s, prefix)
+	}
+
+	if fields := strings.Fields(s); len(fields) == 2 && strings.HasPrefix(fields[1], "epoch") {
+		n, err := strconv.Atoi(fields[0])
+		if err != nil || n <= 0 {
+			return time.Time{}, fmt.Errorf("invalid epoch count %q", fields[0])
+		}
+		return time.Now().Add(-time.Duration(n) * EpochDuration), nil
+	}
+
+	// "7d" and friends: Go durations stop at hours.
+	if strings.HasSuffix(s, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(s, "d")); err == nil && days > 0 {
+			return time.Now().Add(-time.Duration(days) * 24 * time.Hour), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", raw, location()); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("cannot parse time range %q (try \"past 7d\", \"last 2 epochs\", \"72h\" or a date)", raw)
+}
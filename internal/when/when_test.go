@@ -0,0 +1,64 @@
+package when
+
+import (
+	"testing"
+	"time"
+)
+
+// approximately allows the few milliseconds between the time.Now calls
+// inside ParseSince and in the test.
+func approximately(t *testing.T, got time.Time, want time.Duration) {
+	t.Helper()
+	if diff := time.Until(got) + want; diff < -time.Second || diff > time.Second {
+		t.Errorf("cutoff is %v before now, want about %v", time.Since(got), want)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	empty, err := ParseSince("")
+	if err != nil || !empty.IsZero() {
+		t.Errorf("ParseSince(\"\") = %v, %v, want zero time and no error", empty, err)
+	}
+
+	relative := []struct {
+		in  string
+		ago time.Duration
+	}{
+		{"past 7d", 7 * 24 * time.Hour},
+		{"7d", 7 * 24 * time.Hour},
+		{"last 2 epochs", 2 * EpochDuration},
+		{"1 epoch", EpochDuration},
+		{"72h", 72 * time.Hour},
+		{"Past 30M", 30 * time.Minute}, // case-insensitive; lowered to 30m
+	}
+	for _, tt := range relative {
+		got, err := ParseSince(tt.in)
+		if err != nil {
+			t.Errorf("ParseSince(%q): %v", tt.in, err)
+			continue
+		}
+		approximately(t, got, tt.ago)
+	}
+
+	date, err := ParseSince("2024-03-02")
+	if err != nil {
+		t.Fatalf("ParseSince(date): %v", err)
+	}
+	if y, m, d := date.Date(); y != 2024 || m != time.March || d != 2 {
+		t.Errorf("ParseSince(date) = %v, want 2024-03-02", date)
+	}
+
+	stamp, err := ParseSince("2024-03-02T14:05:00Z")
+	if err != nil {
+		t.Fatalf("ParseSince(RFC3339): %v", err)
+	}
+	if !stamp.Equal(time.Date(2024, 3, 2, 14, 5, 0, 0, time.UTC)) {
+		t.Errorf("ParseSince(RFC3339) = %v", stamp)
+	}
+
+	for _, in := range []string{"soon", "-5h", "0d", "x epochs"} {
+		if _, err := ParseSince(in); err == nil {
+			t.Errorf("ParseSince(%q) succeeded, want error", in)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Package workers is the house pattern for structured concurrency: an
+// errgroup with context propagation and per-worker panic containment.
+// Long-running subsystems spawn goroutines through a Group so the first
+// failure cancels the siblings and a panicking worker surfaces as an
+// error naming the worker instead of crashing the process.
+package workers
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Group owns a set of related workers sharing one context.
+type Group struct {
+	eg  *errgroup.Group
+	ctx context.Context
+}
+
+// New creates a Group; the returned context is cancelled when any
+// worker fails or the parent context expires.
+func New(ctx context.Context) (*Group, context.Context) {
+	eg, ctx := errgroup.WithContext(ctx)
+	return &Group{eg: eg, ctx: ctx}, ctx
+}
+
+// Go starts a named worker. A panic inside the worker is recovered and
+// reported as that worker's error, cancelling the rest of the group.
+func (g *Group) Go(name string, fn func(ctx context.Context) error) {
+	g.eg.Go(func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("worker %s panicked: %v\n%s", name, r, debug.Stack())
+			}
+		}()
+		return fn(g.ctx)
+	})
+}
+
+// Wait blocks until every worker returned and yields the first error.
+func (g *Group) Wait() error {
+	return g.eg.Wait()
+}
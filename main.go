@@ -0,0 +1,7 @@
+package main
+
+import "github.com/leveleven/smtool/cmd"
+
+func main() {
+	cmd.Execute()
+}
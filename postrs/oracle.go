@@ -0,0 +1,181 @@
+package postrs
+
+/*
+#include <stdint.h>
+
+typedef void* (*new_initializer_fn)(int32_t provider_id, uintptr_t n,
+	const uint8_t* commitment, const uint8_t* vrf_difficulty);
+typedef int32_t (*initialize_fn)(void* initializer, uint64_t start, uint64_t end,
+	uint8_t* out_buffer, uint64_t* out_nonce);
+typedef void (*free_initializer_fn)(void* initializer);
+
+static void* call_new_initializer(void* fn, int32_t provider_id, uintptr_t n,
+	const uint8_t* commitment, const uint8_t* vrf_difficulty) {
+	return ((new_initializer_fn)fn)(provider_id, n, commitment, vrf_difficulty);
+}
+static int32_t call_initialize(void* fn, void* initializer, uint64_t start, uint64_t end,
+	uint8_t* out_buffer, uint64_t* out_nonce) {
+	return ((initialize_fn)fn)(initializer, start, end, out_buffer, out_nonce);
+}
+static void call_free_initializer(void* fn, void* initializer) {
+	((free_initializer_fn)fn)(initializer);
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"unsafe"
+
+	"github.com/zeebo/blake3"
+)
+
+// ErrProvider marks failures reported by libpost itself — initializer
+// creation, label computation, provider enumeration — as opposed to bad
+// arguments from the caller. Callers match it with errors.Is to map
+// provider trouble to a distinct exit status.
+var ErrProvider = errors.New("provider error")
+
+// WorkOracle computes labels (and optionally searches for a VRF nonce)
+// through a loaded libpost initializer.
+type WorkOracle struct {
+	lib         *library
+	initializer unsafe.Pointer
+
+	// lastEnd tracks the highest position handed out so far, used to
+	// reject overlapping or regressing ranges.
+	lastEnd *uint64
+}
+
+// WorkOracleResult holds the labels computed for a position range and,
+// when a VRF difficulty was set and a position qualified, the nonce.
+type WorkOracleResult struct {
+	Output []byte
+	Nonce  *uint64
+}
+
+type oracleOptions struct {
+	libraryPath string
+	providerID  int
+	commitment  []byte
+	difficulty  []byte
+}
+
+// OracleOption configures a WorkOracle.
+type OracleOption func(*oracleOptions)
+
+// WithProviderID selects the compute provider used for label generation.
+func WithProviderID(id int) OracleOption {
+	return func(o *oracleOptions) { o.providerID = id }
+}
+
+// WithCommitment sets the 32-byte commitment the labels are derived from.
+func WithCommitment(commitment []byte) OracleOption {
+	return func(o *oracleOptions) { o.commitment = commitment }
+}
+
+// WithVRFDifficulty sets the 32-byte threshold a label must beat to
+// qualify as a VRF nonce.
+func WithVRFDifficulty(difficulty []byte) OracleOption {
+	return func(o *oracleOptions) { o.difficulty = difficulty }
+}
+
+// WithLibraryPath loads libpost from an explicit path instead of the
+// default linker search path.
+func WithLibraryPath(path string) OracleOption {
+	return func(o *oracleOptions) { o.libraryPath = path }
+}
+
+// CommitmentBytes derives the 32-byte commitment labels are computed
+// from, hashing the node ID and commitment ATX ID the same way the
+// node and libpost do.
+func CommitmentBytes(nodeID, commitmentAtxID []byte) []byte {
+	hash := blake3.New()
+	hash.Write(nodeID)
+	hash.Write(commitmentAtxID)
+	return hash.Sum(nil)
+}
+
+// NewOracle creates a WorkOracle backed by a libpost initializer.
+func NewOracle(opts ...OracleOption) (*WorkOracle, error) {
+	options := &oracleOptions{
+		libraryPath: DefaultLibrary,
+		providerID:  CPUProviderID,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if len(options.commitment) != 32 {
+		return nil, fmt.Errorf("commitment must be 32 bytes, got %d", len(options.commitment))
+	}
+
+	lib, err := open(options.libraryPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var difficulty *C.uint8_t
+	if len(options.difficulty) == 32 {
+		difficulty = (*C.uint8_t)(unsafe.Pointer(&options.difficulty[0]))
+	}
+	initializer := C.call_new_initializer(
+		lib.newInitializer,
+		C.int32_t(options.providerID),
+		C.uintptr_t(8192),
+		(*C.uint8_t)(unsafe.Pointer(&options.commitment[0])),
+		difficulty,
+	)
+	if initializer == nil {
+		lib.close()
+		return nil, fmt.Errorf("%w: libpost failed to create initializer for provider %d", ErrProvider, options.providerID)
+	}
+	return &WorkOracle{lib: lib, initializer: initializer}, nil
+}
+
+// Positions computes the labels for positions [start, end] inclusive.
+// Ranges must be ascending and non-overlapping across calls; a range
+// ending at MaxUint64 is rejected because its inclusive size would wrap
+// around.
+func (w *WorkOracle) Positions(start, end uint64) (WorkOracleResult, error) {
+	if start > end {
+		return WorkOracleResult{}, fmt.Errorf("invalid range: start %d > end %d", start, end)
+	}
+	if end == math.MaxUint64 {
+		return WorkOracleResult{}, fmt.Errorf("invalid range: end %d would overflow the inclusive range size", end)
+	}
+	if w.lastEnd != nil && start <= *w.lastEnd {
+		return WorkOracleResult{}, fmt.Errorf("overlapping range: start %d not after previous end %d", start, *w.lastEnd)
+	}
+	count := end - start + 1
+	output := make([]byte, count*LabelSize)
+	nonce := C.uint64_t(0)
+
+	retval := C.call_initialize(
+		w.lib.initialize,
+		w.initializer,
+		C.uint64_t(start),
+		C.uint64_t(end),
+		(*C.uint8_t)(unsafe.Pointer(&output[0])),
+		&nonce,
+	)
+	switch retval {
+	case 0:
+		w.lastEnd = &end
+		return WorkOracleResult{Output: output}, nil
+	case 1:
+		w.lastEnd = &end
+		value := uint64(nonce)
+		return WorkOracleResult{Output: output, Nonce: &value}, nil
+	default:
+		return WorkOracleResult{}, fmt.Errorf("%w: libpost initialize failed with code %d", ErrProvider, int32(retval))
+	}
+}
+
+// Close releases the initializer and the underlying library handle.
+func (w *WorkOracle) Close() error {
+	C.call_free_initializer(w.lib.freeInitializer, w.initializer)
+	w.lib.close()
+	return nil
+}
@@ -0,0 +1,177 @@
+// Package postrs provides bindings to the libpost (post-rs) shared
+// library used for label computation, VRF nonce search and proof
+// verification. The library is loaded with dlopen so that smtool can
+// run against whatever libpost version is installed on the host, or
+// against an explicitly chosen build.
+package postrs
+
+/*
+#cgo LDFLAGS: -ldl
+#include <dlfcn.h>
+#include <stdint.h>
+#include <stdlib.h>
+
+typedef const char* (*version_fn)(void);
+
+static const char* call_version(void* fn) {
+	return ((version_fn)fn)();
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// DefaultLibrary is the soname used when no explicit library path is
+// given; dlopen resolves it through the regular linker search path.
+const DefaultLibrary = "libpost.so"
+
+// CPUProviderID is the provider id libpost reserves for its CPU
+// (scrypt) implementation.
+const CPUProviderID = -1
+
+// LabelSize is the size in bytes of a single label in the POST data.
+const LabelSize = 16
+
+// Available probes for a usable libpost and returns its version. The
+// error describes what is missing (library not found, missing symbols).
+func Available(path string) (version string, err error) {
+	if path == "" {
+		path = DefaultLibrary
+	}
+	lib, err := open(path)
+	if err != nil {
+		return "", err
+	}
+	defer lib.close()
+	return lib.Version(), nil
+}
+
+// Optional libpost feature groups. Label computation is the only part
+// every supported library version has; the rest arrived over time, and
+// an older build simply lacks the symbols.
+const (
+	FeatureVerification = "proof verification"
+	FeatureProving      = "proof generation"
+	FeatureProviders    = "provider enumeration"
+)
+
+// MissingFeature describes an entry point absent from a loaded library
+// and the first libpost release that provides it.
+type MissingFeature struct {
+	Feature string
+	Symbol  string
+	Since   string
+}
+
+// MissingFeatures reports which optional features the library at path
+// lacks; an empty slice is a fully featured build. The error is non-nil
+// only when the library cannot be loaded at all.
+func MissingFeatures(path string) ([]MissingFeature, error) {
+	if path == "" {
+		path = DefaultLibrary
+	}
+	lib, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer lib.close()
+	return lib.missing, nil
+}
+
+// library is a loaded libpost instance with its resolved entry points.
+type library struct {
+	path   string
+	handle unsafe.Pointer
+
+	newInitializer  unsafe.Pointer
+	initialize      unsafe.Pointer
+	freeInitializer unsafe.Pointer
+	newVerifier     unsafe.Pointer
+	verifyProof     unsafe.Pointer
+	freeVerifier    unsafe.Pointer
+	generateProof   unsafe.Pointer
+	freeProof       unsafe.Pointer
+
+	getProvidersCount unsafe.Pointer
+	getProviders      unsafe.Pointer
+	version           unsafe.Pointer
+
+	// missing records optional entry points this build does not export;
+	// the features using them fail with a descriptive error instead of
+	// the load failing outright.
+	missing []MissingFeature
+}
+
+// open dlopens the library at path and resolves its entry points. Only
+// the label computation symbols are required; newer entry points are
+// recorded as missing so callers can degrade gracefully on old builds.
+func open(path string) (*library, error) {
+	cPath := C.CString(path)
+	defer C.free(unsafe.Pointer(cPath))
+
+	handle := C.dlopen(cPath, C.RTLD_NOW|C.RTLD_LOCAL)
+	if handle == nil {
+		return nil, fmt.Errorf("dlopen %s: %s", path, C.GoString(C.dlerror()))
+	}
+
+	lib := &library{path: path, handle: handle}
+	for _, symbol := range []struct {
+		name    string
+		dst     *unsafe.Pointer
+		feature string // empty means required at load time
+		since   string // libpost release introducing the symbol
+	}{
+		{"new_initializer", &lib.newInitializer, "", ""},
+		{"initialize", &lib.initialize, "", ""},
+		{"free_initializer", &lib.freeInitializer, "", ""},
+		{"version", &lib.version, "", ""},
+		{"new_verifier", &lib.newVerifier, FeatureVerification, "0.4.0"},
+		{"verify_proof", &lib.verifyProof, FeatureVerification, "0.4.0"},
+		{"free_verifier", &lib.freeVerifier, FeatureVerification, "0.4.0"},
+		{"generate_proof", &lib.generateProof, FeatureProving, "0.6.0"},
+		{"free_proof", &lib.freeProof, FeatureProving, "0.6.0"},
+		{"get_providers_count", &lib.getProvidersCount, FeatureProviders, "0.3.0"},
+		{"get_providers", &lib.getProviders, FeatureProviders, "0.3.0"},
+	} {
+		cName := C.CString(symbol.name)
+		*symbol.dst = C.dlsym(handle, cName)
+		C.free(unsafe.Pointer(cName))
+		if *symbol.dst != nil {
+			continue
+		}
+		if symbol.feature == "" {
+			C.dlclose(handle)
+			return nil, fmt.Errorf("%s: missing symbol %s", path, symbol.name)
+		}
+		lib.missing = append(lib.missing, MissingFeature{
+			Feature: symbol.feature,
+			Symbol:  symbol.name,
+			Since:   symbol.since,
+		})
+	}
+	return lib, nil
+}
+
+// require returns a descriptive error when the feature's entry points
+// were not resolved, naming the library version that provides them.
+func (l *library) require(feature string) error {
+	for _, m := range l.missing {
+		if m.Feature == feature {
+			return fmt.Errorf("%s (version %s) lacks %s: symbol %s requires libpost >= %s",
+				l.path, l.Version(), feature, m.Symbol, m.Since)
+		}
+	}
+	return nil
+}
+
+// Version returns the version string reported by the loaded library.
+func (l *library) Version() string {
+	return C.GoString(C.call_version(l.version))
+}
+
+func (l *library) close() {
+	C.dlclose(l.handle)
+}
@@ -0,0 +1,110 @@
+package postrs
+
+/*
+#include <stdint.h>
+#include <stdlib.h>
+
+typedef struct {
+	uint32_t nonce;
+	uint8_t* indices;
+	uintptr_t indices_len;
+	uint64_t pow;
+} post_proof_t;
+
+typedef post_proof_t* (*generate_proof_fn)(const char* datadir,
+	const uint8_t* challenge, uintptr_t challenge_len,
+	uint32_t k1, uint32_t k2, const uint8_t* pow_difficulty,
+	uintptr_t nonces, uintptr_t threads, uintptr_t pow_flags);
+typedef void (*free_proof_fn)(post_proof_t* proof);
+
+static post_proof_t* call_generate_proof(void* fn, const char* datadir,
+	const uint8_t* challenge, uintptr_t challenge_len,
+	uint32_t k1, uint32_t k2, const uint8_t* pow_difficulty,
+	uintptr_t nonces, uintptr_t threads, uintptr_t pow_flags) {
+	return ((generate_proof_fn)fn)(datadir, challenge, challenge_len,
+		k1, k2, pow_difficulty, nonces, threads, pow_flags);
+}
+static void call_free_proof(void* fn, post_proof_t* proof) {
+	((free_proof_fn)fn)(proof);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// Proof is a generated POST proof.
+type Proof struct {
+	Nonce   uint32
+	Indices []byte
+	Pow     uint64
+}
+
+// RandomX flags for the k2pow engine. Light needs ~256 MiB of memory
+// and works everywhere; Fast trades ~2 GiB for much faster hashing.
+const (
+	RandomXLight uint = 0
+	RandomXFast  uint = 1 << 2
+)
+
+// ProofParams are the tunable parameters of proof generation. K1 and K2
+// follow the protocol config; PowDifficulty is the 32-byte k2pow
+// threshold. Zero Nonces/Threads let libpost pick its defaults;
+// PowFlags selects the RandomX mode (RandomXLight by default).
+type ProofParams struct {
+	K1            uint32
+	K2            uint32
+	PowDifficulty []byte
+	Nonces        uint
+	Threads       uint
+	PowFlags      uint
+}
+
+// GenerateProof runs libpost's prover over an initialized plot
+// directory with explicit parameters. libraryPath may be empty for the
+// default search path.
+func GenerateProof(libraryPath, dataDir string, challenge []byte, params ProofParams) (*Proof, error) {
+	if libraryPath == "" {
+		libraryPath = DefaultLibrary
+	}
+	if len(params.PowDifficulty) != 32 {
+		return nil, fmt.Errorf("pow difficulty must be 32 bytes, got %d", len(params.PowDifficulty))
+	}
+	if len(challenge) == 0 {
+		return nil, fmt.Errorf("challenge must not be empty")
+	}
+	lib, err := open(libraryPath)
+	if err != nil {
+		return nil, err
+	}
+	defer lib.close()
+	if err := lib.require(FeatureProving); err != nil {
+		return nil, err
+	}
+
+	cDir := C.CString(dataDir)
+	defer C.free(unsafe.Pointer(cDir))
+
+	cProof := C.call_generate_proof(
+		lib.generateProof,
+		cDir,
+		(*C.uint8_t)(unsafe.Pointer(&challenge[0])), C.uintptr_t(len(challenge)),
+		C.uint32_t(params.K1), C.uint32_t(params.K2),
+		(*C.uint8_t)(unsafe.Pointer(&params.PowDifficulty[0])),
+		C.uintptr_t(params.Nonces), C.uintptr_t(params.Threads),
+		C.uintptr_t(params.PowFlags),
+	)
+	if cProof == nil {
+		return nil, fmt.Errorf("libpost failed to generate a proof for %s", dataDir)
+	}
+	defer C.call_free_proof(lib.freeProof, cProof)
+
+	proof := &Proof{
+		Nonce:   uint32(cProof.nonce),
+		Indices: C.GoBytes(unsafe.Pointer(cProof.indices), C.int(cProof.indices_len)),
+		Pow:     uint64(cProof.pow),
+	}
+	return proof, nil
+}
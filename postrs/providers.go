@@ -0,0 +1,94 @@
+package postrs
+
+/*
+#include <stdint.h>
+
+typedef struct {
+	uint32_t id;
+	int32_t device_class;
+	char name[256];
+} post_provider_t;
+
+typedef uintptr_t (*get_providers_count_fn)(void);
+typedef int32_t (*get_providers_fn)(post_provider_t* out, uintptr_t count);
+
+static uintptr_t call_get_providers_count(void* fn) {
+	return ((get_providers_count_fn)fn)();
+}
+static int32_t call_get_providers(void* fn, post_provider_t* out, uintptr_t count) {
+	return ((get_providers_fn)fn)(out, count);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// DeviceClass distinguishes provider hardware types.
+type DeviceClass int32
+
+const (
+	ClassCPU DeviceClass = 1
+	ClassGPU DeviceClass = 2
+)
+
+func (c DeviceClass) String() string {
+	switch c {
+	case ClassCPU:
+		return "CPU"
+	case ClassGPU:
+		return "GPU"
+	default:
+		return fmt.Sprintf("class %d", int32(c))
+	}
+}
+
+// Provider describes one compute provider libpost can initialize with.
+type Provider struct {
+	// ID is the value to pass as the provider id.
+	ID uint32
+	// Class reports whether this is the CPU implementation or an
+	// OpenCL device.
+	Class DeviceClass
+	// Model is the device name reported by the driver.
+	Model string
+}
+
+// Providers enumerates the compute providers the loaded libpost can
+// use, CPU and OpenCL devices alike. libraryPath may be empty for the
+// default search path.
+func Providers(libraryPath string) ([]Provider, error) {
+	if libraryPath == "" {
+		libraryPath = DefaultLibrary
+	}
+	lib, err := open(libraryPath)
+	if err != nil {
+		return nil, err
+	}
+	defer lib.close()
+	if err := lib.require(FeatureProviders); err != nil {
+		return nil, err
+	}
+
+	count := uintptr(C.call_get_providers_count(lib.getProvidersCount))
+	if count == 0 {
+		return nil, nil
+	}
+	raw := make([]C.post_provider_t, count)
+	retval := C.call_get_providers(lib.getProviders, &raw[0], C.uintptr_t(count))
+	if retval != 0 {
+		return nil, fmt.Errorf("%w: libpost provider enumeration failed with code %d", ErrProvider, int32(retval))
+	}
+
+	providers := make([]Provider, 0, count)
+	for _, entry := range raw {
+		providers = append(providers, Provider{
+			ID:    uint32(entry.id),
+			Class: DeviceClass(entry.device_class),
+			Model: C.GoString((*C.char)(unsafe.Pointer(&entry.name[0]))),
+		})
+	}
+	return providers, nil
+}
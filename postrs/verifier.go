@@ -0,0 +1,92 @@
+package postrs
+
+/*
+#include <stdint.h>
+
+typedef void* (*new_verifier_fn)(int32_t mode);
+typedef int32_t (*verify_proof_fn)(const void* verifier,
+	const uint8_t* proof, uintptr_t proof_len,
+	const uint8_t* metadata, uintptr_t metadata_len);
+typedef void (*free_verifier_fn)(void* verifier);
+
+static void* call_new_verifier(void* fn, int32_t mode) {
+	return ((new_verifier_fn)fn)(mode);
+}
+static int32_t call_verify_proof(void* fn, const void* verifier,
+	const uint8_t* proof, uintptr_t proof_len,
+	const uint8_t* metadata, uintptr_t metadata_len) {
+	return ((verify_proof_fn)fn)(verifier, proof, proof_len, metadata, metadata_len);
+}
+static void call_free_verifier(void* fn, void* verifier) {
+	((free_verifier_fn)fn)(verifier);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// Verifier validates POST proofs through a loaded libpost instance.
+// Multiple verifiers backed by different library builds can coexist in
+// one process, which is what verifyproof's cross-checking relies on.
+type Verifier struct {
+	lib      *library
+	verifier unsafe.Pointer
+}
+
+// NewVerifier loads libpost from path (or the default search path when
+// path is empty) and creates a verifier instance.
+func NewVerifier(path string) (*Verifier, error) {
+	if path == "" {
+		path = DefaultLibrary
+	}
+	lib, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := lib.require(FeatureVerification); err != nil {
+		lib.close()
+		return nil, err
+	}
+	verifier := C.call_new_verifier(lib.newVerifier, 0)
+	if verifier == nil {
+		lib.close()
+		return nil, fmt.Errorf("%s: failed to create verifier", path)
+	}
+	return &Verifier{lib: lib, verifier: verifier}, nil
+}
+
+// Path returns the library path this verifier was loaded from.
+func (v *Verifier) Path() string {
+	return v.lib.path
+}
+
+// Version returns the libpost version string of the loaded library.
+func (v *Verifier) Version() string {
+	return v.lib.Version()
+}
+
+// Verify checks a scale-encoded proof against scale-encoded proof
+// metadata. It returns nil when the proof is valid and an error
+// describing the failure otherwise.
+func (v *Verifier) Verify(proof, metadata []byte) error {
+	retval := C.call_verify_proof(
+		v.lib.verifyProof,
+		v.verifier,
+		(*C.uint8_t)(unsafe.Pointer(&proof[0])), C.uintptr_t(len(proof)),
+		(*C.uint8_t)(unsafe.Pointer(&metadata[0])), C.uintptr_t(len(metadata)),
+	)
+	if retval != 0 {
+		return fmt.Errorf("proof is invalid (code %d)", int32(retval))
+	}
+	return nil
+}
+
+// Close releases the verifier and the underlying library handle.
+func (v *Verifier) Close() error {
+	C.call_free_verifier(v.lib.freeVerifier, v.verifier)
+	v.lib.close()
+	return nil
+}
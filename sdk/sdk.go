@@ -0,0 +1,144 @@
+// Package sdk is smtool's stable public API for downstream Go tools.
+//
+// Unlike the internal packages, which change freely between releases,
+// sdk follows semantic versioning: within a major version existing
+// functions keep their signatures and behavior, and new capabilities
+// arrive as new fields on the options structs (whose zero values always
+// mean "default"). Downstream code should depend on this package only.
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/leveleven/smtool/postrs"
+	"github.com/spacemeshos/post/shared"
+)
+
+// NonceSearchOptions configures SearchNonce. Commitment is required;
+// every other field has a usable zero value.
+type NonceSearchOptions struct {
+	// Commitment is the 32-byte value labels are derived from.
+	Commitment []byte
+	// Difficulty is the 32-byte threshold a label must beat. When nil,
+	// no nonce search is performed and SearchNonce returns an error.
+	Difficulty []byte
+	// Provider selects the compute provider; nil means the CPU. It is a
+	// pointer because libpost numbers real devices from 0, so 0 is a
+	// selectable provider, not a usable sentinel.
+	Provider *int
+	// Library overrides the libpost path.
+	Library string
+	// Start is the first position to scan.
+	Start uint64
+	// MaxPositions bounds the scan; zero means 1<<32.
+	MaxPositions uint64
+	// BatchSize is the number of positions per oracle call; zero means
+	// 128Ki.
+	BatchSize uint64
+}
+
+// NonceResult describes a found VRF nonce.
+type NonceResult struct {
+	Nonce uint64
+	// Label is the 16-byte label at the nonce position.
+	Label []byte
+	// Scanned is the number of positions examined.
+	Scanned uint64
+}
+
+// SearchNonce scans label positions until one beats the difficulty or
+// the position budget is exhausted (in which case it returns nil, nil).
+func SearchNonce(ctx context.Context, opts NonceSearchOptions) (*NonceResult, error) {
+	if len(opts.Difficulty) != 32 {
+		return nil, fmt.Errorf("difficulty must be 32 bytes, got %d", len(opts.Difficulty))
+	}
+	oracleOpts := []postrs.OracleOption{
+		postrs.WithCommitment(opts.Commitment),
+		postrs.WithVRFDifficulty(opts.Difficulty),
+	}
+	if opts.Provider != nil {
+		oracleOpts = append(oracleOpts, postrs.WithProviderID(*opts.Provider))
+	}
+	if opts.Library != "" {
+		oracleOpts = append(oracleOpts, postrs.WithLibraryPath(opts.Library))
+	}
+	oracle, err := postrs.NewOracle(oracleOpts...)
+	if err != nil {
+		return nil, err
+	}
+	defer oracle.Close()
+
+	maxPositions := opts.MaxPositions
+	if maxPositions == 0 {
+		maxPositions = 1 << 32
+	}
+	batch := opts.BatchSize
+	if batch == 0 {
+		batch = 128 * 1024
+	}
+
+	scanned := uint64(0)
+	for start := opts.Start; start < opts.Start+maxPositions; start += batch {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		end := start + batch - 1
+		if limit := opts.Start + maxPositions - 1; end > limit {
+			end = limit
+		}
+		result, err := oracle.Positions(start, end)
+		if err != nil {
+			return nil, err
+		}
+		scanned += end - start + 1
+		if result.Nonce != nil {
+			offset := (*result.Nonce - start) * postrs.LabelSize
+			label := make([]byte, postrs.LabelSize)
+			copy(label, result.Output[offset:offset+postrs.LabelSize])
+			return &NonceResult{Nonce: *result.Nonce, Label: label, Scanned: scanned}, nil
+		}
+	}
+	return nil, nil
+}
+
+// VerifyOptions configures VerifyProof.
+type VerifyOptions struct {
+	// Proof and Metadata are the serialized inputs as the node stores
+	// them.
+	Proof    []byte
+	Metadata []byte
+	// Library overrides the libpost path.
+	Library string
+}
+
+// VerifyProof checks a POST proof against its metadata; nil means valid.
+func VerifyProof(opts VerifyOptions) error {
+	library := opts.Library
+	if library == "" {
+		library = postrs.DefaultLibrary
+	}
+	verifier, err := postrs.NewVerifier(library)
+	if err != nil {
+		return err
+	}
+	defer verifier.Close()
+	return verifier.Verify(opts.Proof, opts.Metadata)
+}
+
+// LoadMetadata reads and parses postdata_metadata.json from a plot
+// directory.
+func LoadMetadata(dir string) (*shared.PostMetadata, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "postdata_metadata.json"))
+	if err != nil {
+		return nil, err
+	}
+	metadata := new(shared.PostMetadata)
+	if err := json.Unmarshal(data, metadata); err != nil {
+		return nil, fmt.Errorf("parse metadata: %w", err)
+	}
+	return metadata, nil
+}